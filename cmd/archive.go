@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Export the files changed between two references as a ZIP, without prompts",
+	Long: `Export the files changed between --start and --end as a ZIP archive. Unlike
+compare, both references are required and nothing is asked interactively,
+which makes it suitable for scripts and CI.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := expandRangeFlags(); err != nil {
+			return err
+		}
+		// --since also fills in --end with the branch tip
+		hasStart := startRef != "" || sinceDate != ""
+		hasEnd := endRef != "" || sinceDate != "" || untilDate != ""
+		if (!hasStart || !hasEnd) && tagRef == "" {
+			return usageErrorf("archive requires --start (or --since) and --end (or --until), or --tag")
+		}
+
+		return runCompare(cmd, args)
+	},
+}
+
+func init() {
+	addRangeFlags(archiveCmd.Flags(), "Start commit/branch (required unless --tag or --since is set)", "End commit/branch (required unless --tag, --since or --until is set)")
+	addDiffFlags(archiveCmd.Flags())
+	addArchiveFlags(archiveCmd.Flags())
+
+	rootCmd.AddCommand(archiveCmd)
+}
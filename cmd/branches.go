@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchesSort   string
+	branchesMatch  string
+	branchesStale  time.Duration
+	branchesBase   string
+	branchesFormat string
+)
+
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "List the branches of a repository",
+	Long: `List local and remote-tracking branches, sorted by last commit date (newest
+first), name or author. Branches can be narrowed by a name glob or to those
+without commits for a while, and compared against a base branch to show how
+many commits each is ahead of and behind it.
+
+EXAMPLES:
+  # Feature branches untouched for 90 days, with their divergence from main
+  githubCompare branches --local . --match 'feature/**' --stale 2160h --base main
+
+  # Machine-readable listing for cleanup scripts
+  githubCompare branches --repo https://github.com/owner/repo --base main --format json`,
+	RunE: runBranches,
+}
+
+func runBranches(cmd *cobra.Command, args []string) error {
+	if branchesFormat != display.FormatText && branchesFormat != display.FormatJSON {
+		return usageErrorf("unsupported branches format %q (expected text or json)", branchesFormat)
+	}
+	if branchesFormat == display.FormatJSON {
+		display.SetOutput(os.Stderr)
+	}
+
+	c, cleanup, err := openRepository(cmd.Context(), "Branches", false)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	repo := handle.Repo(c)
+
+	branches, err := git.ListBranches(cmd.Context(), repo)
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches, err = git.FilterBranches(branches, branchesMatch, branchesStale, time.Now())
+	if err != nil {
+		return &usageError{err: err}
+	}
+	if err := git.SortBranches(branches, branchesSort); err != nil {
+		return &usageError{err: err}
+	}
+
+	if branchesBase != "" {
+		if err := git.CountAheadBehind(cmd.Context(), repo, branchesBase, branches); err != nil {
+			return fmt.Errorf("failed to compare branches: %w", err)
+		}
+	}
+
+	if branchesFormat == display.FormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(branches); err != nil {
+			return fmt.Errorf("failed to write json output: %w", err)
+		}
+		return nil
+	}
+
+	display.PrintBranches(branches)
+	return nil
+}
+
+func init() {
+	branchesCmd.Flags().StringVar(&branchesSort, "sort", git.SortByDate, "Sort by date (newest first), name or author")
+	branchesCmd.Flags().StringVar(&branchesMatch, "match", "", "Only list branches whose name matches this glob (\"**\" spans \"/\")")
+	branchesCmd.Flags().DurationVar(&branchesStale, "stale", 0, "Only list branches whose last commit is older than this, e.g. 720h")
+	branchesCmd.Flags().StringVar(&branchesBase, "base", "", "Show how many commits each branch is ahead of and behind this branch")
+	branchesCmd.Flags().StringVarP(&branchesFormat, "format", "f", display.FormatText, "Output format: text or json")
+
+	rootCmd.AddCommand(branchesCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var pruneOlderThan time.Duration
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage cached repository mirrors",
+	Long: `Remote repositories are cached as bare mirrors so repeated comparisons only
+fetch new objects. These commands inspect and clean up that cache.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached mirrors",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mirrors, err := git.ListMirrors(cacheDir)
+		if err != nil {
+			return fmt.Errorf("failed to list mirrors: %w", err)
+		}
+
+		display.PrintMirrors(cacheDir, mirrors)
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove mirrors that have not been used recently",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := git.PruneMirrors(cacheDir, pruneOlderThan)
+		if err != nil {
+			return fmt.Errorf("failed to prune mirrors: %w", err)
+		}
+
+		display.PrintRemovedMirrors(removed)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached mirrors",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := git.ClearMirrors(cacheDir)
+		if err != nil {
+			return fmt.Errorf("failed to clear mirrors: %w", err)
+		}
+
+		display.PrintRemovedMirrors(removed)
+		return nil
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 30*24*time.Hour, "Remove mirrors unused for longer than this")
+
+	cacheCmd.AddCommand(cacheListCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -1,206 +1,296 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/githubCompare/internal/archive"
 	"github.com/githubCompare/internal/display"
 	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
 	"github.com/githubCompare/internal/interactive"
-	"github.com/githubCompare/internal/utils"
+	"github.com/githubCompare/pkg/compare"
+	"github.com/mattn/go-isatty"
 )
 
-func runCompare(cmd *cobra.Command, args []string) {
-	// Parse repository URL
-	repoInfo, err := utils.ParseRepoURL(repoURL)
+// commitDisplayLimit caps how many commits of the range are printed
+const commitDisplayLimit = 20
+
+// stdoutPath is the --output value that streams the archive to stdout
+const stdoutPath = "-"
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare two commits/branches and export the changed files (default)",
+	Long: `Compare two commits or branches and export only the changed files as a ZIP
+archive. Missing --start/--end values are picked interactively.
+
+This is also what runs when githubCompare is called without a subcommand.`,
+	RunE: runCompare,
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	structured, err := checkDiffFlags()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing repository URL: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	// Create temp directory
-	tempDir, err := utils.CreateTempDir("githubCompare-")
+	c, result, cleanup, err := loadComparison(cmd.Context(), "GitHub Compare")
+	defer cleanup()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating temp directory: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	// Cleanup temp directory unless --no-cleanup is set
-	if !noCleanup {
-		defer func() {
-			if err := utils.CleanupTemp(tempDir); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to cleanup temp directory: %v\n", err)
+	startShort, endShort := shortRef(result.Base()), shortRef(result.End.Ref)
+	if len(result.Changes) == 0 {
+		if structured {
+			if err := printResult(result); err != nil {
+				return err
 			}
-		}()
-	}
-
-	// Display header
-	display.PrintHeader("GitHub Compare")
-	fmt.Printf("\n")
-	display.Info.Printf("Repository: %s\n", repoURL)
-	if repoInfo.Name != "" {
-		display.Info.Printf("Project: %s/%s\n", repoInfo.Owner, repoInfo.Name)
+		}
+		return &git.EmptyRangeError{Start: startShort, End: endShort}
 	}
-	fmt.Println()
 
-	display.PrintSection("Cloning Repository")
-	fmt.Printf("  Cloning %s...\n", repoURL)
+	// Display changes summary
+	display.PrintSummary(startShort, endShort, result.Changes)
+	display.PrintChanges(result.Changes)
 
-	// Clone repository
-	cloneOpts := git.CloneOptions{
-		URL:       repoURL,
-		AuthToken: authToken,
-		TempDir:   tempDir,
+	// Generate output path if not provided
+	if outputPath == "" {
+		outputPath = archive.GenerateOutputName(result.Repository.Name, startShort, endShort)
 	}
 
-	repoPath, err := git.CloneRepository(cloneOpts)
+	display.PrintSection("Creating Archive")
+	if outputPath == stdoutPath {
+		display.Printf("  Output: stdout\n")
+		err = c.WriteArchiveTo(cmd.Context(), os.Stdout, result)
+	} else {
+		display.Printf("  Output: %s\n", outputPath)
+		err = c.WriteArchive(cmd.Context(), result, outputPath)
+	}
 	if err != nil {
-		display.PrintError(fmt.Sprintf("Failed to clone repository: %v", err))
-		os.Exit(1)
+		return err
+	}
+	if patchPath != "" {
+		display.PrintSuccess(fmt.Sprintf("Patch written: %s", patchPath))
 	}
 
-	display.PrintSuccess("Repository cloned successfully")
+	display.PrintHeader("Complete!")
+	if outputPath == stdoutPath {
+		display.PrintSuccess("Archive written to stdout")
+	} else {
+		display.PrintSuccess(fmt.Sprintf("Archive created: %s", result.Archive))
+	}
+	display.Count.Printf("  Changed files: %d\n", len(result.Changes))
 
-	// List branches
-	display.PrintSection("Fetching Branches")
-	branches, err := git.ListBranches(repoPath)
-	if err != nil {
-		display.PrintError(fmt.Sprintf("Failed to list branches: %v", err))
-		os.Exit(1)
+	if noCleanup && result.Repository.Protocol != "file" && noCache && !inMemory {
+		display.Info.Printf("  Temp directory kept: %s\n", c.Path())
 	}
+	display.Println()
 
-	// If both start and end are provided, skip interactive selection
-	var startCommit, endCommit string
-	if startRef != "" && endRef != "" {
-		startCommit = startRef
-		endCommit = endRef
-		display.Info.Printf("Using start reference: %s\n", startRef)
-		display.Info.Printf("Using end reference: %s\n", endRef)
-		fmt.Println()
-	} else {
-		// Select branch if not provided
-		selectedBranch := endRef
-		if selectedBranch == "" {
-			selectedBranch, err = interactive.SelectBranch(branches)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error selecting branch: %v\n", err)
-				os.Exit(1)
-			}
-		}
+	if structured {
+		return printResult(result)
+	}
+	return nil
+}
 
-		// List commits for selected branch
-		display.PrintSection(fmt.Sprintf("Fetching Commits for Branch '%s'", selectedBranch))
-		commits, err := git.ListCommits(repoPath, selectedBranch, 100)
-		if err != nil {
-			display.PrintError(fmt.Sprintf("Failed to list commits: %v", err))
-			os.Exit(1)
-		}
+// checkDiffFlags validates the flags shared by the diffing commands and
+// reports whether structured output was requested
+func checkDiffFlags() (bool, error) {
+	if err := display.ValidateFormat(outputFormat); err != nil {
+		return false, &usageError{err: err}
+	}
+	// "A...B" in either ref is shorthand for --start A --end B --merge-base
+	if err := expandRangeFlags(); err != nil {
+		return false, err
+	}
 
-		if len(commits) == 0 {
-			display.PrintWarning(fmt.Sprintf("No commits found for branch %s", selectedBranch))
-			os.Exit(1)
+	// Keep stdout clean for machine-readable output
+	structured := outputFormat != display.FormatText
+	if outputPath == stdoutPath {
+		if structured {
+			return false, usageErrorf("--output - and --format %s both write to stdout", outputFormat)
 		}
-
-		// Select start commit
-		if startRef != "" {
-			startCommit = startRef
-			display.Info.Printf("Using start reference: %s\n", startRef)
-		} else {
-			startCommit, err = interactive.SelectCommit(commits, "Select START commit (older commit):")
-			if err != nil {
-				display.PrintError(fmt.Sprintf("Failed to select start commit: %v", err))
-				os.Exit(1)
-			}
+		if isatty.IsTerminal(os.Stdout.Fd()) {
+			return false, usageErrorf("refusing to write the archive to a terminal; redirect or pipe stdout")
 		}
+	}
+	if structured || outputPath == stdoutPath {
+		display.SetOutput(os.Stderr)
+	}
+	return structured, nil
+}
 
-		// Select end commit
-		if endRef != "" {
-			endCommit = endRef
-			display.Info.Printf("Using end reference: %s\n", endRef)
-		} else {
-			endCommit, err = interactive.SelectCommit(commits, "Select END commit (newer commit):")
-			if err != nil {
-				display.PrintError(fmt.Sprintf("Failed to select end commit: %v", err))
-				os.Exit(1)
-			}
-		}
+// loadComparison opens the repository, selects the references (prompting for
+// any that were not given), and compares them. The returned cleanup function
+// removes a temporary clone and must be called even when an error is returned.
+func loadComparison(ctx context.Context, title string) (*compare.Comparer, *compare.Result, func(), error) {
+	c, cleanup, err := openRepository(ctx, title, true)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	repo := handle.Repo(c)
+
+	if err := applyTagFlag(repo); err != nil {
+		return nil, nil, cleanup, err
+	}
+	if err := applyDateFlags(repo); err != nil {
+		return nil, nil, cleanup, err
+	}
+	startCommit, endCommit, err := selectRefs(ctx, repo)
+	if err != nil {
+		return nil, nil, cleanup, err
 	}
 
-	// Validate refs
 	display.PrintSection("Validating References")
-	if err := git.ValidateRefs(repoPath, startCommit, endCommit); err != nil {
-		display.PrintError(fmt.Sprintf("Reference validation failed: %v", err))
-		os.Exit(1)
+	result, err := c.Compare(ctx, startCommit, endCommit)
+	if err != nil {
+		return nil, nil, cleanup, err
 	}
 	display.PrintSuccess("References validated")
 
-	// Get changed files
+	if result.MergeBase != "" {
+		display.PrintInfo(fmt.Sprintf("Merge base of %s and %s: %s", startCommit, endCommit, result.MergeBase[:7]))
+	}
+	display.PrintCommits(result.Commits, commitDisplayLimit)
+
 	display.PrintSection("Comparing Changes")
-	fileChanges, err := git.GetChangedFiles(repoPath, startCommit, endCommit)
+	if len(result.Changes) != result.TotalChanges {
+		display.PrintInfo(fmt.Sprintf("Path filters kept %d of %d changed files", len(result.Changes), result.TotalChanges))
+	}
+
+	return c, result, cleanup, nil
+}
+
+// diffOptions returns the change detection options selected by the diff flags
+func diffOptions() git.DiffOptions {
+	return git.DiffOptions{
+		DetectRenames:   !noRenames,
+		DetectCopies:    findCopies,
+		RenameThreshold: renameThreshold,
+		LineStats:       !noStat,
+	}
+}
+
+// selectRefs returns the start and end references from --start/--end,
+// prompting for a branch and commits when either is missing
+func selectRefs(ctx context.Context, repo *git.Repository) (string, string, error) {
+	// If both start and end are provided, skip interactive selection
+	if startRef != "" && endRef != "" {
+		display.Info.Printf("Using start reference: %s\n", startRef)
+		display.Info.Printf("Using end reference: %s\n", endRef)
+		display.Println()
+		return startRef, endRef, nil
+	}
+
+	// List branches and tags
+	display.PrintSection("Fetching Branches")
+	branches, err := git.ListBranches(ctx, repo)
 	if err != nil {
-		display.PrintError(fmt.Sprintf("Failed to compare changes: %v", err))
-		os.Exit(1)
+		return "", "", fmt.Errorf("failed to list branches: %w", err)
+	}
+	tags, err := git.ListTags(repo)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list tags: %w", err)
 	}
 
-	if len(fileChanges) == 0 {
-		display.PrintWarning("No files changed between the selected commits.")
-		os.Exit(0)
+	// Select branch or tag if not provided
+	selectedBranch := endRef
+	if selectedBranch == "" {
+		selectedBranch, err = interactive.SelectRef(branches, tags)
+		if err != nil {
+			return "", "", err
+		}
 	}
 
-	// Display changes summary
-	startShort := startCommit
-	endShort := endCommit
-	if len(startCommit) > 7 {
-		startShort = startCommit[:7]
+	// Load the branch history a page at a time as the prompts need it
+	display.PrintSection(fmt.Sprintf("Fetching Commits for Branch '%s'", selectedBranch))
+	history := interactive.NewCommitList(func(skip, limit int) ([]git.Commit, error) {
+		return git.ListCommitsPage(ctx, repo, selectedBranch, skip, limit)
+	})
+	if err := history.LoadMore(); err != nil {
+		return "", "", fmt.Errorf("failed to list commits: %w", err)
 	}
-	if len(endCommit) > 7 {
-		endShort = endCommit[:7]
+
+	if len(history.Commits()) == 0 {
+		return "", "", fmt.Errorf("no commits found for branch %s", selectedBranch)
 	}
-	display.PrintSummary(startShort, endShort, len(fileChanges))
-	display.PrintChanges(fileChanges)
 
-	// Generate output path if not provided
-	if outputPath == "" {
-		outputPath = archive.GenerateOutputName(repoInfo.Name, startShort, endShort)
+	// Select start commit
+	startCommit := startRef
+	if startRef != "" {
+		display.Info.Printf("Using start reference: %s\n", startRef)
+	} else {
+		startCommit, err = interactive.SelectCommit(history, "Select START commit (older commit):")
+		if err != nil {
+			return "", "", err
+		}
 	}
 
-	// Ensure output directory exists
-	if err := archive.EnsureOutputDir(outputPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
-		os.Exit(1)
+	// Select end commit, asking again until it is newer than the start
+	endCommit := endRef
+	if endRef != "" {
+		display.Info.Printf("Using end reference: %s\n", endRef)
+		return startCommit, endCommit, nil
 	}
+	for {
+		endCommit, err = interactive.SelectCommit(history, "Select END commit (newer commit):")
+		if err != nil {
+			return "", "", err
+		}
 
-	// Convert git.FileChange to archive.FileChange
-	archiveChanges := make([]archive.FileChange, len(fileChanges))
-	for i, fc := range fileChanges {
-		archiveChanges[i] = archive.FileChange{
-			Path:       fc.Path,
-			ChangeType: fc.ChangeType,
-			OldPath:    fc.OldPath,
+		newer, err := descendsFrom(repo, startCommit, endCommit)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to check commit order: %w", err)
+		}
+		if newer {
+			break
 		}
+		display.PrintWarning(fmt.Sprintf("%s is not newer than the start commit %s; pick a commit that builds on it", shortRef(endCommit), shortRef(startCommit)))
 	}
 
-	// Create ZIP archive
-	display.PrintSection("Creating Archive")
-	fmt.Printf("  Output: %s\n", outputPath)
-	
-	if err := archive.CreateZipFromChanges(repoPath, archiveChanges, outputPath); err != nil {
-		display.PrintError(fmt.Sprintf("Failed to create ZIP archive: %v", err))
-		os.Exit(1)
+	return startCommit, endCommit, nil
+}
+
+// descendsFrom reports whether end is a different commit that has start in
+// its history
+func descendsFrom(repo *git.Repository, start, end string) (bool, error) {
+	startHash, err := git.GetCommitHash(repo, start)
+	if err != nil {
+		return false, err
 	}
+	endHash, err := git.GetCommitHash(repo, end)
+	if err != nil {
+		return false, err
+	}
+	if startHash == endHash {
+		return false, nil
+	}
+	return git.IsAncestor(repo, startHash, endHash)
+}
 
-	// Get absolute path for display
-	absPath, _ := filepath.Abs(outputPath)
-	
-	display.PrintHeader("Complete!")
-	display.PrintSuccess(fmt.Sprintf("Archive created: %s", absPath))
-	display.Count.Printf("  Changed files: %d\n", len(fileChanges))
-	
-	if noCleanup {
-		display.Info.Printf("  Temp directory kept: %s\n", repoPath)
+// shortRef abbreviates a reference to at most 7 characters for display
+func shortRef(ref string) string {
+	if len(ref) > 7 {
+		return ref[:7]
 	}
-	fmt.Println()
+	return ref
+}
+
+// printResult writes the comparison result to stdout in the selected format
+func printResult(result *compare.Result) error {
+	if err := display.PrintResult(os.Stdout, outputFormat, *result); err != nil {
+		return fmt.Errorf("failed to write %s output: %w", outputFormat, err)
+	}
+	return nil
+}
+
+func init() {
+	addRangeFlags(compareCmd.Flags(), promptStartUsage, promptEndUsage)
+	addDiffFlags(compareCmd.Flags())
+	addArchiveFlags(compareCmd.Flags())
+
+	rootCmd.AddCommand(compareCmd)
 }
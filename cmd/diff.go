@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/githubCompare/internal/archive"
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/pkg/compare"
+	"github.com/spf13/cobra"
+)
+
+var diffPatch string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the files changed between two references without creating an archive",
+	Long: `Show the files changed between --start and --end with their line statistics,
+or as JSON or YAML with --format. --patch also writes a git apply-compatible
+unified diff to a file, like compare does; "--patch -" writes it to stdout in
+place of the change list. Missing references are picked interactively.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		structured, err := checkDiffFlags()
+		if err != nil {
+			return err
+		}
+		if diffPatch == stdoutPath {
+			if structured {
+				return usageErrorf("--patch - cannot be combined with --format")
+			}
+			display.SetOutput(os.Stderr)
+		}
+
+		c, result, cleanup, err := loadComparison(cmd.Context(), "Diff")
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+
+		startShort, endShort := shortRef(result.Base()), shortRef(result.End.Ref)
+		switch {
+		case diffPatch == stdoutPath:
+			if err := c.WritePatch(cmd.Context(), os.Stdout, result); err != nil {
+				return err
+			}
+		case structured:
+			if err := printResult(result); err != nil {
+				return err
+			}
+		default:
+			display.PrintSummary(startShort, endShort, result.Changes)
+			display.PrintChanges(result.Changes)
+		}
+		if diffPatch != "" && diffPatch != stdoutPath {
+			if err := writePatchFile(cmd.Context(), c, result, diffPatch); err != nil {
+				return err
+			}
+			display.PrintSuccess(fmt.Sprintf("Patch written: %s", diffPatch))
+		}
+
+		if len(result.Changes) == 0 {
+			return &git.EmptyRangeError{Start: startShort, End: endShort}
+		}
+		return nil
+	},
+}
+
+// writePatchFile writes the unified diff of result to path, removing the file
+// again if it could not be written completely
+func writePatchFile(ctx context.Context, c *compare.Comparer, result *compare.Result, path string) error {
+	if err := archive.EnsureOutputDir(path); err != nil {
+		return fmt.Errorf("failed to create patch directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create patch file: %w", err)
+	}
+	if err := c.WritePatch(ctx, file, result); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	addRangeFlags(diffCmd.Flags(), promptStartUsage, promptEndUsage)
+	addDiffFlags(diffCmd.Flags())
+	diffCmd.Flags().StringVar(&diffPatch, "patch", "", "Also write a git apply-compatible unified diff to this file (\"-\" writes it to stdout instead of the change list)")
+
+	rootCmd.AddCommand(diffCmd)
+}
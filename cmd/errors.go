@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/githubCompare/internal/git"
+)
+
+// Exit codes, documented in the README
+const (
+	exitOK          = 0
+	exitError       = 1   // Any failure not listed below
+	exitUsage       = 2   // Invalid flags or arguments
+	exitNotFound    = 3   // Repository, reference or commit not found
+	exitAmbiguous   = 4   // Abbreviated hash matches several commits
+	exitAuth        = 5   // Authentication required or rejected
+	exitNetwork     = 6   // Remote could not be reached
+	exitEmptyRange  = 7   // No files changed between the references
+	exitTimeout     = 124 // --timeout expired
+	exitInterrupted = 130 // Interrupted with Ctrl+C or SIGTERM
+)
+
+// usageError reports invalid flags or arguments
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+// usageErrorf formats a usageError
+func usageErrorf(format string, a ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, a...)}
+}
+
+// exitCode returns the process exit code for an error returned by a command
+func exitCode(err error) int {
+	var (
+		usage     *usageError
+		notFound  *git.NotFoundError
+		ambiguous *git.AmbiguousRefError
+		auth      *git.AuthError
+		network   *git.NetworkError
+		empty     *git.EmptyRangeError
+	)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, terminal.InterruptErr):
+		return exitInterrupted
+	case errors.As(err, &empty):
+		return exitEmptyRange
+	case errors.As(err, &ambiguous):
+		return exitAmbiguous
+	case errors.As(err, &auth):
+		return exitAuth
+	case errors.As(err, &notFound):
+		return exitNotFound
+	case errors.As(err, &network):
+		return exitNetwork
+	}
+	return exitError
+}
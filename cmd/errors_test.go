@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/githubCompare/internal/git"
+)
+
+func TestExitCode(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("failed to compare: %w", err) }
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"other", errors.New("boom"), exitError},
+		{"usage", usageErrorf("bad flag"), exitUsage},
+		{"wrapped usage", wrap(usageErrorf("bad flag")), exitUsage},
+		{"not found", wrap(&git.NotFoundError{Kind: "reference", Name: "main"}), exitNotFound},
+		{"ambiguous", wrap(&git.AmbiguousRefError{Ref: "abc"}), exitAmbiguous},
+		{"auth", wrap(&git.AuthError{URL: "https://example.com/repo.git", Err: errors.New("denied")}), exitAuth},
+		{"network", wrap(&git.NetworkError{URL: "https://example.com/repo.git", Err: errors.New("unreachable")}), exitNetwork},
+		{"empty range", wrap(&git.EmptyRangeError{Start: "a", End: "b"}), exitEmptyRange},
+		{"deadline", wrap(context.DeadlineExceeded), exitTimeout},
+		{"canceled", wrap(context.Canceled), exitInterrupted},
+		{"prompt interrupted", wrap(terminal.InterruptErr), exitInterrupted},
+		// A timeout that surfaces as a network failure is still a timeout
+		{"deadline in network error", &git.NetworkError{URL: "https://example.com/repo.git", Err: context.DeadlineExceeded}, exitTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/spf13/cobra"
+)
+
+var logLimit int
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List commits on a branch or between two references",
+	Long: `List the commits reachable from --end (default HEAD). With --start, only the
+commits that are not reachable from it are listed, like "git log start..end".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := expandRangeFlags(); err != nil {
+			return err
+		}
+		c, cleanup, err := openRepository(cmd.Context(), "Commit Log", true)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+		repo := handle.Repo(c)
+
+		if err := applyTagFlag(repo); err != nil {
+			return err
+		}
+		if err := applyDateFlags(repo); err != nil {
+			return err
+		}
+		if endRef == "" {
+			endRef = "HEAD"
+		}
+
+		var commits []git.Commit
+		if startRef != "" {
+			commits, err = git.ListCommitRange(cmd.Context(), repo, startRef, endRef)
+		} else {
+			commits, err = git.ListCommits(cmd.Context(), repo, endRef, logLimit)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list commits: %w", err)
+		}
+
+		display.PrintCommits(commits, logLimit)
+		display.Println()
+		return nil
+	},
+}
+
+func init() {
+	addRangeFlags(logCmd.Flags(), "Only list commits not reachable from this commit/branch", "List commits reachable from this commit/branch (default HEAD)")
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 50, "Maximum number of commits to show (0 for all)")
+
+	rootCmd.AddCommand(logCmd)
+}
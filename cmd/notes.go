@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/githubCompare/internal/notes"
+	"github.com/githubCompare/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notesFormat string
+	notesOutput string
+	notesAll    bool
+)
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Generate release notes from Conventional Commits in a range",
+	Long: `Generate release notes for the commits between --start and --end (default
+HEAD). Subjects following Conventional Commits ("feat(scope)!: ...") are
+grouped into sections, breaking changes are listed first, and commits link to
+the repository host when it is known.
+
+By default only features, fixes, performance improvements and reverts are
+listed; use --all to include every commit.
+
+EXAMPLES:
+  githubCompare notes --repo https://github.com/owner/repo --start v1.0.0 --end v1.1.0
+  githubCompare notes --local . --start v1.0.0 --format json -o notes.json`,
+	RunE: runNotes,
+}
+
+func runNotes(cmd *cobra.Command, args []string) error {
+	if notesFormat != "markdown" && notesFormat != display.FormatJSON {
+		return usageErrorf("unsupported notes format %q (expected markdown or json)", notesFormat)
+	}
+	if err := expandRangeFlags(); err != nil {
+		return err
+	}
+	if startRef == "" && tagRef == "" && sinceDate == "" {
+		return usageErrorf("--start, --since or --tag is required")
+	}
+
+	// The notes themselves go to stdout unless written to a file
+	if notesOutput == "" {
+		display.SetOutput(os.Stderr)
+	}
+
+	c, cleanup, err := openRepository(cmd.Context(), "Release Notes", true)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	repo := handle.Repo(c)
+	repoInfo := c.Repository()
+
+	if err := applyTagFlag(repo); err != nil {
+		return err
+	}
+	if err := applyDateFlags(repo); err != nil {
+		return err
+	}
+	if endRef == "" {
+		endRef = "HEAD"
+	}
+
+	display.PrintSection("Collecting Commits")
+	commits, err := git.ListCommitRange(cmd.Context(), repo, startRef, endRef)
+	if err != nil {
+		return fmt.Errorf("failed to list commits in range: %w", err)
+	}
+	display.PrintSuccess(fmt.Sprintf("Found %d commits between %s and %s", len(commits), startRef, endRef))
+
+	releaseNotes := notes.New(repoInfo.URL, startRef, endRef, commits, notes.Options{
+		All:    notesAll,
+		WebURL: webURL(repoInfo, repo),
+	})
+
+	var data []byte
+	if notesFormat == display.FormatJSON {
+		if data, err = releaseNotes.JSON(); err != nil {
+			return err
+		}
+	} else {
+		data = releaseNotes.Markdown()
+	}
+
+	if notesOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(notesOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write release notes: %w", err)
+	}
+	display.PrintSuccess(fmt.Sprintf("Release notes written: %s", notesOutput))
+	return nil
+}
+
+// webURL returns the browsable address of the repository for links. Local
+// repositories fall back to the URL of their origin remote.
+func webURL(repoInfo *utils.RepoInfo, repo *git.Repository) string {
+	if url := repoInfo.WebURL(); url != "" || repoInfo.Protocol != "file" {
+		return url
+	}
+
+	origin, err := git.RemoteURL(repo, "origin")
+	if err != nil {
+		return ""
+	}
+	originInfo, err := utils.ParseRepoURL(origin)
+	if err != nil {
+		return ""
+	}
+	return originInfo.WebURL()
+}
+
+func init() {
+	addRangeFlags(notesCmd.Flags(), "Commit/tag the notes start after (required unless --tag or --since is set)", "Commit/tag the notes end at (default HEAD)")
+	notesCmd.Flags().StringVarP(&notesFormat, "format", "f", "markdown", "Notes format: markdown or json")
+	notesCmd.Flags().StringVarP(&notesOutput, "output", "o", "", "Write the notes to this file instead of stdout")
+	notesCmd.Flags().BoolVar(&notesAll, "all", false, "Include every commit, not just features, fixes, performance changes and reverts")
+
+	rootCmd.AddCommand(notesCmd)
+}
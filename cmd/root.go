@@ -1,19 +1,55 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/githubCompare/internal/archive"
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	repoURL    string
-	outputPath string
-	startRef   string
-	endRef     string
-	authToken  string
-	noCleanup  bool
+	repoURL      string
+	outputPath   string
+	startRef     string
+	endRef       string
+	authToken    string
+	noCleanup    bool
+	localPath    string
+	noCache      bool
+	inMemory     bool
+	cacheDir     string
+	noManifest   bool
+	outputFormat string
+	patchPath    string
+	embedPatch   bool
+
+	includePatterns []string
+	excludePatterns []string
+	ignoreFile      string
+
+	noRenames       bool
+	findCopies      bool
+	renameThreshold int
+	noStat          bool
+	useMergeBase    bool
+	withChangelog   bool
+	tagRef          string
+	sinceDate       string
+	untilDate       string
+	timeout         time.Duration
+
+	// stopTimeout releases the --timeout deadline once the command returns
+	stopTimeout context.CancelFunc = func() {}
 )
 
 var rootCmd = &cobra.Command{
@@ -23,6 +59,13 @@ var rootCmd = &cobra.Command{
 two Git commits or branches and export only the changed files as a ZIP archive.
 
 It supports both public and private repositories, and can work with HTTPS or SSH URLs.
+Repositories that are already on disk can be used in place with --local.
+
+Remote repositories are kept as bare mirrors in a local cache and fetched
+incrementally on later runs. When --start and --end are both given, a new
+mirror fetches only their range, and the next run without one completes it.
+Use --no-cache to clone into a temporary directory instead, and the "cache"
+command to list, prune or clear mirrors.
 
 INTERACTIVE MODE:
   When you provide only --repo, the tool will guide you through:
@@ -42,25 +85,137 @@ EXAMPLES:
   githubCompare --repo https://github.com/owner/repo --start main --end feature-branch
 
   # With specific commits
-  githubCompare --repo https://github.com/owner/repo --start abc1234 --end def5678`,
-	Run: runCompare,
+  githubCompare --repo https://github.com/owner/repo --start abc1234 --end def5678
+
+  # Existing local repository (no clone)
+  githubCompare --local ./my-repo --start main --end feature-branch
+
+  # Everything that changed on a branch in the last two weeks
+  githubCompare --local ./my-repo --end release --since "2 weeks ago"
+
+COMMANDS:
+  Without a subcommand githubCompare runs "compare". The other commands reuse
+  the same --repo/--local flags, and those that take a range --start/--end:
+  githubCompare branches --repo <url>
+  githubCompare log --local . --start v1.0.0 --end main
+  githubCompare diff --local . --start v1.0.0 --end main --patch -
+  githubCompare archive --local . --start v1.0.0 --end main -o release.zip
+  githubCompare tui --local .
+
+EXIT CODES:
+  0 success, 1 other error, 2 invalid flags, 3 repository/ref not found,
+  4 ambiguous short hash, 5 authentication failed, 6 network error,
+  7 no changes between the references, 124 --timeout expired,
+  130 interrupted with Ctrl+C`,
+	PersistentPreRunE: applyTimeout,
+	RunE:              runCompare,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&repoURL, "repo", "r", "", "Repository URL (required)")
-	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output ZIP file path (optional, auto-generated if not provided)")
-	rootCmd.Flags().StringVarP(&startRef, "start", "s", "", "Start commit/branch (optional, will prompt if not provided)")
-	rootCmd.Flags().StringVarP(&endRef, "end", "e", "", "End commit/branch (optional, will prompt if not provided)")
-	rootCmd.Flags().StringVar(&authToken, "auth-token", "", "Authentication token for private repos (HTTPS)")
-	rootCmd.Flags().BoolVar(&noCleanup, "no-cleanup", false, "Keep temporary directory after execution")
-
-	rootCmd.MarkFlagRequired("repo")
+	// Shared by every subcommand
+	rootCmd.PersistentFlags().StringVarP(&repoURL, "repo", "r", "", "Repository URL or local path (required unless --local is set)")
+	rootCmd.PersistentFlags().StringVarP(&localPath, "local", "l", "", "Path to an existing local repository (skips cloning)")
+	rootCmd.PersistentFlags().StringVar(&authToken, "auth-token", "", "Authentication token for private repos (HTTPS)")
+	rootCmd.PersistentFlags().BoolVar(&noCleanup, "no-cleanup", false, "Keep temporary directory after execution (with --no-cache)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Clone into a temporary directory instead of using the mirror cache")
+	rootCmd.PersistentFlags().BoolVar(&inMemory, "in-memory", false, "Clone into memory, writing nothing but the output to disk (implies --no-cache)")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", utils.GetCacheBase(), "Directory holding cached repository mirrors")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Give up after this long, e.g. 30s or 5m (default no limit)")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+
+	// Running without a subcommand is the compare command
+	addRangeFlags(rootCmd.Flags(), promptStartUsage, promptEndUsage)
+	addDiffFlags(rootCmd.Flags())
+	addArchiveFlags(rootCmd.Flags())
+}
+
+// Usage of --start and --end for the commands that prompt for missing refs
+const (
+	promptStartUsage = "Start commit/branch (optional, will prompt if not provided)"
+	promptEndUsage   = "End commit/branch (optional, will prompt if not provided)"
+)
+
+// addRangeFlags registers the flags that select the range of commits, with
+// the usage of --start and --end for the command
+func addRangeFlags(flags *pflag.FlagSet, startUsage, endUsage string) {
+	flags.StringVarP(&startRef, "start", "s", "", startUsage)
+	flags.StringVarP(&endRef, "end", "e", "", endUsage)
+	flags.StringVar(&tagRef, "tag", "", "Compare this version tag to the previous one by semver (\"latest\" for the newest release)")
+	flags.StringVar(&sinceDate, "since", "", "Start from where the --end branch (default HEAD) was at this date, e.g. \"2 weeks ago\" or 2024-01-31")
+	flags.StringVar(&untilDate, "until", "", "End at where the --end branch (default HEAD) was at this date")
+}
+
+// addDiffFlags registers the flags that control how changes are computed and
+// reported
+func addDiffFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&outputFormat, "format", "f", "text", "Result format: text, json or yaml (structured formats go to stdout, everything else to stderr)")
+	flags.StringArrayVar(&includePatterns, "include", nil, "Only keep changed paths matching this glob or pathspec (repeatable)")
+	flags.StringArrayVar(&excludePatterns, "exclude", nil, "Drop changed paths matching this glob (repeatable)")
+	flags.StringVar(&ignoreFile, "ignore-file", "", "File of exclude globs, one per line (\"!\" re-includes, \"#\" comments)")
+	flags.BoolVar(&noRenames, "no-renames", false, "Report renames as a deletion plus an addition")
+	flags.BoolVar(&findCopies, "find-copies", false, "Report added files that copy an existing file")
+	flags.IntVar(&renameThreshold, "rename-threshold", git.DefaultDiffOptions.RenameThreshold, "Minimum similarity percentage (0-100) for renames and copies")
+	flags.BoolVar(&useMergeBase, "merge-base", false, "Diff from the common ancestor of start and end, like GitHub's compare view (same as start...end)")
+	flags.BoolVar(&noStat, "no-stat", false, "Skip counting added and removed lines per file")
+}
+
+// addArchiveFlags registers the flags that control the ZIP archive and what
+// is written alongside it
+func addArchiveFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&outputPath, "output", "o", "", "Output ZIP file path (optional, auto-generated if not provided; \"-\" streams to stdout)")
+	flags.BoolVar(&noManifest, "no-manifest", false, "Don't embed the change manifest and apply scripts in the archive")
+	flags.StringVar(&patchPath, "patch", "", "Also write a git apply-compatible unified diff to this file")
+	flags.BoolVar(&embedPatch, "embed-patch", false, "Embed the unified diff in the archive as "+archive.PatchName)
+	flags.BoolVar(&withChangelog, "changelog", false, "Embed a log of the range's commits, grouped by date and author, as "+archive.ChangelogName)
+}
+
+// applyTimeout puts the --timeout deadline on the command's context
+func applyTimeout(cmd *cobra.Command, args []string) error {
+	if timeout < 0 {
+		return usageErrorf("--timeout must not be negative, got %s", timeout)
+	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		cmd.SetContext(ctx)
+		stopTimeout = cancel
+	}
+	return nil
 }
 
-// Execute runs the root command
+// Execute runs the root command and exits with the code for the error it
+// returned, if any. Ctrl+C or SIGTERM cancels the command's context, so it can
+// stop and remove partial output; a second signal kills the process.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stopTimeout()
+	stop()
+	if err == nil {
+		return
+	}
+
+	var usage *usageError
+	var empty *git.EmptyRangeError
+	switch {
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "Error: %v\nRun 'githubCompare --help' for usage.\n", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		display.PrintError(fmt.Sprintf("Timed out after %s: %v", timeout, err))
+	case errors.Is(err, context.Canceled):
+		display.PrintWarning("Interrupted")
+	case errors.As(err, &empty):
+		display.PrintWarning(fmt.Sprintf("No files changed between %s and %s.", empty.Start, empty.End))
+	default:
+		display.PrintError(err.Error())
 	}
+	os.Exit(exitCode(err))
 }
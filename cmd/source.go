@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/utils"
+	"github.com/githubCompare/pkg/compare"
+)
+
+// expandRangeFlags turns "A...B" given to --start or --end into both refs and
+// enables merge-base mode
+func expandRangeFlags() error {
+	for _, ref := range []string{startRef, endRef} {
+		start, end, ok := git.SplitRange(ref)
+		if !ok {
+			continue
+		}
+		if startRef != "" && endRef != "" {
+			return usageErrorf("range %q already names both ends; drop the other of --start/--end", ref)
+		}
+		startRef, endRef = start, end
+		useMergeBase = true
+		return nil
+	}
+	return nil
+}
+
+// applyTagFlag expands --tag into --start (the previous version tag) and
+// --end (the tag itself)
+func applyTagFlag(repo *git.Repository) error {
+	if tagRef == "" {
+		return nil
+	}
+	if startRef != "" || endRef != "" {
+		return usageErrorf("--tag cannot be combined with --start or --end")
+	}
+
+	tags, err := git.ListTags(repo)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	name := tagRef
+	if name == "latest" {
+		latest, err := git.LatestTag(tags)
+		if err != nil {
+			return err
+		}
+		name = latest.Name
+	}
+
+	previous, err := git.PreviousTag(tags, name)
+	if err != nil {
+		return fmt.Errorf("failed to find the tag before %s: %w", name, err)
+	}
+
+	display.PrintInfo(fmt.Sprintf("Comparing tag %s to previous tag %s", name, previous.Name))
+	startRef, endRef = previous.Name, name
+	return nil
+}
+
+// applyDateFlags expands --since and --until into --start and --end: the
+// commits the branch named by --end (default HEAD) pointed to at each date
+func applyDateFlags(repo *git.Repository) error {
+	if sinceDate == "" && untilDate == "" {
+		return nil
+	}
+	if tagRef != "" {
+		return usageErrorf("--since/--until cannot be combined with --tag")
+	}
+	if sinceDate != "" && startRef != "" {
+		return usageErrorf("--since cannot be combined with --start")
+	}
+
+	branch := endRef
+	if branch == "" {
+		branch = "HEAD"
+	}
+
+	var since, until time.Time
+	var err error
+	if sinceDate != "" {
+		if since, err = git.ParseDate(sinceDate); err != nil {
+			return usageErrorf("--since: %v", err)
+		}
+	}
+	if untilDate != "" {
+		if until, err = git.ParseDate(untilDate); err != nil {
+			return usageErrorf("--until: %v", err)
+		}
+		if sinceDate != "" && until.Before(since) {
+			return usageErrorf("--until is earlier than --since")
+		}
+	}
+
+	display.PrintSection("Resolving Dates")
+	endRef = branch
+	if untilDate != "" {
+		commit, err := git.CommitAtDate(repo, branch, until)
+		if err != nil {
+			return fmt.Errorf("failed to find %s as of %s: %w", branch, untilDate, err)
+		}
+		display.PrintInfo(fmt.Sprintf("Until %s: %s was at %s (%s)", untilDate, branch, commit.ShortHash, commit.Date.Format("2006-01-02 15:04")))
+		endRef = commit.Hash
+	}
+	if sinceDate != "" {
+		commit, err := git.CommitAtDate(repo, branch, since)
+		if err != nil {
+			return fmt.Errorf("failed to find %s as of %s: %w", branch, sinceDate, err)
+		}
+		display.PrintInfo(fmt.Sprintf("Since %s: %s was at %s (%s)", sinceDate, branch, commit.ShortHash, commit.Date.Format("2006-01-02 15:04")))
+		startRef = commit.Hash
+	}
+	return nil
+}
+
+// newComparer builds a Comparer for the repository named by --repo or
+// --local, with the diff, path filter and archive flags as options. With
+// rangeOnly, a temporary clone or a new mirror fetches only what --start and
+// --end need when both are given.
+func newComparer(rangeOnly bool) (*compare.Comparer, error) {
+	opts := compare.Options{
+		AuthToken:   authToken,
+		KeepClone:   noCleanup,
+		InMemory:    inMemory,
+		Progress:    display.Output(),
+		MergeBase:   useMergeBase,
+		Diff:        diffOptions(),
+		Include:     includePatterns,
+		Exclude:     excludePatterns,
+		IgnoreFile:  ignoreFile,
+		ArchivePath: outputPath,
+		PatchPath:   patchPath,
+		EmbedPatch:  embedPatch,
+		Changelog:   withChangelog,
+		NoManifest:  noManifest,
+	}
+
+	// Work out where the repository comes from
+	switch {
+	case localPath != "" && repoURL != "":
+		return nil, usageErrorf("--repo and --local cannot be used together")
+	case localPath != "":
+		opts.Path = localPath
+	case repoURL == "":
+		return nil, usageErrorf("either --repo or --local is required")
+	case utils.IsLocalPath(repoURL):
+		opts.Path = repoURL
+	default:
+		opts.URL = repoURL
+	}
+	// "-o -" streams the archive to stdout
+	if outputPath == stdoutPath {
+		opts.ArchivePath, opts.ArchiveWriter = "", os.Stdout
+	}
+	if inMemory && (opts.Path != "" || noCleanup) {
+		return nil, usageErrorf("--in-memory cannot be used with a local repository or --no-cleanup")
+	}
+	if !noCache && !inMemory {
+		opts.CacheDir = cacheDir
+	}
+	// --tag and the date flags pick the refs from the full history once cloned
+	if rangeOnly && startRef != "" && endRef != "" && tagRef == "" && sinceDate == "" && untilDate == "" {
+		opts.Start, opts.End = startRef, endRef
+	}
+
+	c, err := compare.New(opts)
+	if err != nil {
+		return nil, &usageError{err: err}
+	}
+	return c, nil
+}
+
+// openRepository opens the repository named by --repo or --local, using the
+// local path in place, syncing the cached mirror or cloning into a temporary
+// directory, and prints the header and progress. Commands that only look at
+// the --start..--end range pass rangeOnly, see newComparer. The returned
+// cleanup function removes a temporary clone; it is never nil, so it can be
+// deferred before checking the error.
+func openRepository(ctx context.Context, title string, rangeOnly bool) (*compare.Comparer, func(), error) {
+	cleanup := func() {}
+
+	c, err := newComparer(rangeOnly)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	cleanup = func() {
+		if err := c.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Display header
+	repoInfo := c.Repository()
+	display.PrintHeader(title)
+	display.Printf("\n")
+	display.Info.Printf("Repository: %s\n", repoInfo.URL)
+	if repoInfo.Owner != "" {
+		display.Info.Printf("Project: %s/%s\n", repoInfo.Owner, repoInfo.Name)
+	} else if repoInfo.Name != "" {
+		display.Info.Printf("Project: %s\n", repoInfo.Name)
+	}
+	display.Println()
+
+	isLocal := repoInfo.Protocol == "file"
+	switch {
+	case isLocal:
+		display.PrintSection("Opening Local Repository")
+	case inMemory:
+		display.PrintSection("Cloning Repository")
+		display.Printf("  Cloning %s into memory...\n", repoURL)
+	case !noCache:
+		display.PrintSection("Updating Cached Mirror")
+	default:
+		display.PrintSection("Cloning Repository")
+		display.Printf("  Cloning %s...\n", repoURL)
+	}
+
+	if err := c.Open(ctx); err != nil {
+		return nil, cleanup, err
+	}
+
+	switch {
+	case isLocal:
+		display.PrintSuccess(fmt.Sprintf("Using local repository at %s", c.Path()))
+	case inMemory:
+		display.PrintSuccess("Repository cloned into memory")
+	case !noCache:
+		display.Printf("  Mirror: %s\n", c.Path())
+		display.PrintSuccess("Mirror up to date")
+	default:
+		display.PrintSuccess("Repository cloned successfully")
+	}
+
+	return c, cleanup, nil
+}
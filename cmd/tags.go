@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/spf13/cobra"
+)
+
+var tagsFormat string
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List the tags of a repository, newest version first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tagsFormat != display.FormatText && tagsFormat != display.FormatJSON {
+			return usageErrorf("unsupported tags format %q (expected text or json)", tagsFormat)
+		}
+		if tagsFormat == display.FormatJSON {
+			display.SetOutput(os.Stderr)
+		}
+
+		c, cleanup, err := openRepository(cmd.Context(), "Tags", false)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+		repo := handle.Repo(c)
+
+		tags, err := git.ListTags(repo)
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		if tagsFormat == display.FormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(tags); err != nil {
+				return fmt.Errorf("failed to write json output: %w", err)
+			}
+			return nil
+		}
+
+		display.PrintTags(tags)
+		return nil
+	},
+}
+
+func init() {
+	tagsCmd.Flags().StringVarP(&tagsFormat, "format", "f", display.FormatText, "Output format: text or json")
+
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/githubCompare/internal/archive"
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/githubCompare/internal/tui"
+	"github.com/githubCompare/pkg/compare"
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse branches, commits and diffs in a full-screen terminal UI",
+	Long: `Browse the repository in a full-screen terminal UI: pick a branch or tag,
+scroll its commits (older pages load as you go), mark a start commit with s
+and an end commit with e, and press enter to list the changed files. Toggle
+files in or out of the archive with space, open a file's diff with enter and
+export with x. Archives can be exported repeatedly without leaving the UI.
+With --merge-base, the marked commits are compared from their common
+ancestor, so the end commit does not have to build on the start commit.
+
+The diff and archive flags (--include, --exclude, --changelog, --patch, ...)
+apply to every export. Without --output each archive gets a generated name.`,
+	RunE: runTUI,
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	if outputFormat != display.FormatText {
+		return usageErrorf("--format is not supported by tui")
+	}
+	if outputPath == stdoutPath {
+		return usageErrorf("--output - is not supported by tui, which draws on stdout")
+	}
+	if _, err := checkDiffFlags(); err != nil {
+		return err
+	}
+
+	c, cleanup, err := openRepository(cmd.Context(), "Browser", false)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	repoInfo, repo := c.Repository(), handle.Repo(c)
+
+	branches, err := git.ListBranches(cmd.Context(), repo)
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+	tags, err := git.ListTags(repo)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	exported, err := tui.Run(cmd.Context(), tui.Options{
+		Repo:       repo,
+		Title:      repoInfo.URL,
+		Branches:   branches,
+		Tags:       tags,
+		Compare:    c.Compare,
+		WritePatch: c.WritePatch,
+		Export: func(ctx context.Context, result *compare.Result) (string, error) {
+			output := outputPath
+			if output == "" {
+				output = archive.GenerateOutputName(repoInfo.Name, shortRef(result.Base()), shortRef(result.End.Ref))
+			}
+			if err := c.WriteArchive(ctx, result, output); err != nil {
+				return "", err
+			}
+			return result.Archive, nil
+		},
+	})
+	for _, path := range exported {
+		display.PrintSuccess(fmt.Sprintf("Archive created: %s", path))
+	}
+	return err
+}
+
+func init() {
+	addDiffFlags(tuiCmd.Flags())
+	addArchiveFlags(tuiCmd.Flags())
+
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChangelogName is the path of the embedded changelog inside the archive
+const ChangelogName = ManifestDir + "/CHANGELOG.md"
+
+// Commit represents a commit in the range (imported from git package)
+type Commit struct {
+	ShortHash string
+	Message   string
+	Author    string
+	Date      time.Time
+}
+
+// Changelog renders the commits of a range, newest first, as Markdown grouped
+// by day and then by author
+func Changelog(repository, start, end string, commits []Commit) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changes in %s\n\n", repository)
+	fmt.Fprintf(&b, "From `%s` to `%s`, %d commit(s).\n", start, end, len(commits))
+
+	// Days and authors keep the order of their first (newest) commit
+	days := []string{}
+	authors := make(map[string][]string)
+	byAuthor := make(map[string]map[string][]Commit)
+	for _, commit := range commits {
+		day := commit.Date.Format("2006-01-02")
+		if _, ok := byAuthor[day]; !ok {
+			days = append(days, day)
+			byAuthor[day] = make(map[string][]Commit)
+		}
+		if _, ok := byAuthor[day][commit.Author]; !ok {
+			authors[day] = append(authors[day], commit.Author)
+		}
+		byAuthor[day][commit.Author] = append(byAuthor[day][commit.Author], commit)
+	}
+
+	for _, day := range days {
+		fmt.Fprintf(&b, "\n## %s\n", day)
+		for _, author := range authors[day] {
+			fmt.Fprintf(&b, "\n### %s\n\n", author)
+			for _, commit := range byAuthor[day][author] {
+				fmt.Fprintf(&b, "- %s (`%s`)\n", commit.Message, commit.ShortHash)
+			}
+		}
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangelog(t *testing.T) {
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	commits := []Commit{
+		{ShortHash: "c3", Message: "third", Author: "Bob", Date: day2},
+		{ShortHash: "c2", Message: "second", Author: "Alice", Date: day1.Add(2 * time.Hour)},
+		{ShortHash: "c1", Message: "first", Author: "Bob", Date: day1.Add(time.Hour)},
+		{ShortHash: "c0", Message: "zeroth", Author: "Alice", Date: day1},
+	}
+
+	got := string(Changelog("repo", "a", "b", commits))
+	want := "# Changes in repo\n\n" +
+		"From `a` to `b`, 4 commit(s).\n" +
+		"\n## 2024-03-02\n" +
+		"\n### Bob\n\n- third (`c3`)\n" +
+		"\n## 2024-03-01\n" +
+		"\n### Alice\n\n- second (`c2`)\n- zeroth (`c0`)\n" +
+		"\n### Bob\n\n- first (`c1`)\n"
+	if got != want {
+		t.Errorf("Changelog() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Count(got, "### Alice") != 1 {
+		t.Errorf("Changelog() repeated an author heading within a day")
+	}
+}
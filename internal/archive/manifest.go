@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestDir is the directory inside the archive that holds the manifest
+// and the generated apply scripts
+const ManifestDir = ".githubcompare"
+
+// Manifest describes every change in the archive, including the paths that
+// are not shipped because they were deleted or renamed away
+type Manifest struct {
+	Repository  string          `json:"repository"`
+	Start       string          `json:"start"`
+	End         string          `json:"end"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Changes     []ManifestEntry `json:"changes"`
+	Removed     []string        `json:"removed"` // Paths the apply scripts delete
+}
+
+// ManifestEntry is a single change recorded in the manifest
+type ManifestEntry struct {
+	Type       string `json:"type"`
+	Path       string `json:"path"`
+	OldPath    string `json:"old_path,omitempty"`
+	Similarity int    `json:"similarity,omitempty"` // Percentage, for renames and copies
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// NewManifest builds the manifest for the given range of changes
+func NewManifest(repository, start, end string, changes []FileChange) *Manifest {
+	manifest := &Manifest{
+		Repository:  repository,
+		Start:       start,
+		End:         end,
+		GeneratedAt: time.Now().UTC(),
+		Changes:     make([]ManifestEntry, len(changes)),
+	}
+
+	for i, change := range changes {
+		manifest.Changes[i] = ManifestEntry{
+			Type:       change.ChangeType,
+			Path:       change.Path,
+			OldPath:    change.OldPath,
+			Similarity: change.Similarity,
+			Additions:  change.Additions,
+			Deletions:  change.Deletions,
+			Binary:     change.Binary,
+		}
+	}
+	manifest.Removed = removedPaths(changes)
+
+	return manifest
+}
+
+// removedPaths returns the paths that must be deleted from a deployed tree:
+// deleted files and the old side of renames, unless something else still
+// lives at that path in the end commit
+func removedPaths(changes []FileChange) []string {
+	present := make(map[string]bool)
+	for _, change := range changes {
+		if change.ChangeType != "deleted" {
+			present[change.Path] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	removed := []string{}
+	for _, change := range changes {
+		path := ""
+		switch change.ChangeType {
+		case "deleted":
+			path = change.Path
+		case "renamed":
+			path = change.OldPath
+		}
+		if path == "" || present[path] || seen[path] {
+			continue
+		}
+		seen[path] = true
+		removed = append(removed, path)
+	}
+
+	sort.Strings(removed)
+	return removed
+}
+
+// JSON returns the indented JSON encoding of the manifest
+func (m *Manifest) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ShellScript returns a POSIX shell script that removes the deleted and
+// renamed-away files from a tree the archive was extracted over
+func (m *Manifest) ShellScript() []byte {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Generated by githubCompare for %s (%s..%s).\n", m.Repository, m.Start, m.End)
+	b.WriteString("# Extract the archive over the deployed tree, then run:\n")
+	b.WriteString("#   sh " + ManifestDir + "/apply.sh [target-dir]\n")
+	b.WriteString("set -e\n")
+	b.WriteString("cd \"${1:-$(dirname \"$0\")/..}\"\n")
+
+	for _, path := range m.Removed {
+		quoted := shellQuote(path)
+		fmt.Fprintf(&b, "rm -f -- %s\n", quoted)
+		if strings.Contains(path, "/") {
+			fmt.Fprintf(&b, "rmdir -p -- \"$(dirname -- %s)\" 2>/dev/null || true\n", quoted)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// PowerShellScript returns the PowerShell equivalent of ShellScript
+func (m *Manifest) PowerShellScript() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by githubCompare for %s (%s..%s).\n", m.Repository, m.Start, m.End)
+	b.WriteString("# Extract the archive over the deployed tree, then run:\n")
+	b.WriteString("#   powershell -File " + ManifestDir + "/apply.ps1 [-Target <dir>]\n")
+	b.WriteString("param([string]$Target = (Join-Path $PSScriptRoot '..'))\n")
+	b.WriteString("$ErrorActionPreference = 'Stop'\n")
+	b.WriteString("Set-Location -LiteralPath $Target\n")
+
+	for _, path := range m.Removed {
+		fmt.Fprintf(&b, "Remove-Item -LiteralPath %s -Force -ErrorAction SilentlyContinue\n", powerShellQuote(path))
+	}
+
+	return []byte(b.String())
+}
+
+// shellQuote quotes s for use as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuote quotes s as a PowerShell literal string
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,44 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewManifestRemovedPaths(t *testing.T) {
+	changes := []FileChange{
+		{Path: "src/new.go", ChangeType: "renamed", OldPath: "src/old.go"},
+		{Path: "docs/gone.md", ChangeType: "deleted"},
+		{Path: "README.md", ChangeType: "modified"},
+		// Renamed away, but a new file took its place
+		{Path: "b.txt", ChangeType: "renamed", OldPath: "a.txt"},
+		{Path: "a.txt", ChangeType: "added"},
+	}
+
+	manifest := NewManifest("repo", "abc1234", "def5678", changes)
+
+	want := []string{"docs/gone.md", "src/old.go"}
+	if strings.Join(manifest.Removed, ",") != strings.Join(want, ",") {
+		t.Errorf("Removed = %v, want %v", manifest.Removed, want)
+	}
+
+	if len(manifest.Changes) != len(changes) {
+		t.Errorf("Changes has %d entries, want %d", len(manifest.Changes), len(changes))
+	}
+}
+
+func TestManifestScripts(t *testing.T) {
+	manifest := NewManifest("repo", "a", "b", []FileChange{
+		{Path: "it's here.txt", ChangeType: "deleted"},
+	})
+
+	shell := string(manifest.ShellScript())
+	if !strings.Contains(shell, `rm -f -- 'it'\''s here.txt'`) {
+		t.Errorf("ShellScript() did not quote path correctly:\n%s", shell)
+	}
+
+	ps := string(manifest.PowerShellScript())
+	if !strings.Contains(ps, `Remove-Item -LiteralPath 'it''s here.txt'`) {
+		t.Errorf("PowerShellScript() did not quote path correctly:\n%s", ps)
+	}
+}
@@ -2,29 +2,55 @@ package archive
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/githubCompare/internal/git"
 )
 
-// CreateZipFromChanges creates a ZIP archive containing only the changed files
-func CreateZipFromChanges(repoPath string, changes []FileChange, outputPath string) error {
-	// Create the ZIP file
-	zipFile, err := os.Create(outputPath)
+// ZipOptions controls the extra content written alongside the changed files
+type ZipOptions struct {
+	Manifest  *Manifest // Manifest and apply scripts to embed; nil to omit
+	Patch     []byte    // Unified diff to embed as PatchName; nil to omit
+	Changelog []byte    // Commit log to embed as ChangelogName; nil to omit
+}
+
+// PatchName is the path of the embedded unified diff inside the archive
+const PatchName = ManifestDir + "/changes.patch"
+
+// WriteZip streams a ZIP archive containing only the changed files to w.
+// File contents are read from the tree of endRef, so the archive always
+// matches the compared revision regardless of what is checked out in
+// repo. Nothing is buffered beyond the file being compressed, so w can
+// be a pipe; what was written before a failure or cancellation is left to
+// the caller.
+func WriteZip(ctx context.Context, w io.Writer, repo *git.Repository, endRef string, changes []FileChange, opts ZipOptions) error {
+	endCommit, err := git.GetCommit(repo, endRef)
+	if err != nil {
+		return fmt.Errorf("failed to load end commit: %w", err)
+	}
+
+	tree, err := endCommit.Tree()
 	if err != nil {
-		return fmt.Errorf("failed to create ZIP file: %w", err)
+		return fmt.Errorf("failed to get end tree: %w", err)
 	}
-	defer zipFile.Close()
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	zipWriter := zip.NewWriter(w)
 
 	// Track added files to avoid duplicates
 	addedFiles := make(map[string]bool)
 
 	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Skip deleted files (or handle them differently if needed)
 		if change.ChangeType == "deleted" {
 			continue
@@ -35,55 +61,106 @@ func CreateZipFromChanges(repoPath string, changes []FileChange, outputPath stri
 			continue
 		}
 
-		filePath := filepath.Join(repoPath, change.Path)
-
-		// Check if file exists
-		info, err := os.Stat(filePath)
+		file, err := tree.File(change.Path)
 		if err != nil {
-			// File might not exist if it was deleted in the end commit
-			continue
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			continue
+			// Submodules and other non-file entries have no blob to archive
+			if errors.Is(err, object.ErrFileNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to read %s from end commit: %w", change.Path, err)
 		}
 
 		// Add file to ZIP
-		if err := addFileToZip(zipWriter, filePath, change.Path); err != nil {
+		if err := addBlobToZip(zipWriter, file, change.Path, endCommit.Committer.When); err != nil {
 			return fmt.Errorf("failed to add file %s to ZIP: %w", change.Path, err)
 		}
 
 		addedFiles[change.Path] = true
 	}
 
+	if opts.Manifest != nil {
+		if err := addManifestToZip(zipWriter, opts.Manifest, endCommit.Committer.When); err != nil {
+			return fmt.Errorf("failed to add manifest to ZIP: %w", err)
+		}
+	}
+
+	if opts.Patch != nil {
+		if err := addDataToZip(zipWriter, PatchName, opts.Patch, 0644, endCommit.Committer.When); err != nil {
+			return fmt.Errorf("failed to add patch to ZIP: %w", err)
+		}
+	}
+
+	if opts.Changelog != nil {
+		if err := addDataToZip(zipWriter, ChangelogName, opts.Changelog, 0644, endCommit.Committer.When); err != nil {
+			return fmt.Errorf("failed to add changelog to ZIP: %w", err)
+		}
+	}
+
+	// Write the central directory
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish ZIP: %w", err)
+	}
 	return nil
 }
 
-// addFileToZip adds a single file to the ZIP archive
-func addFileToZip(zipWriter *zip.Writer, filePath, zipPath string) error {
-	// Open the file
-	file, err := os.Open(filePath)
+// addManifestToZip writes the manifest and its apply scripts under ManifestDir
+func addManifestToZip(zipWriter *zip.Writer, manifest *Manifest, modTime time.Time) error {
+	manifestJSON, err := manifest.JSON()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Get file info
-	info, err := file.Stat()
+	files := []struct {
+		name string
+		data []byte
+		mode os.FileMode
+	}{
+		{"manifest.json", manifestJSON, 0644},
+		{"apply.sh", manifest.ShellScript(), 0755},
+		{"apply.ps1", manifest.PowerShellScript(), 0644},
+	}
+
+	for _, file := range files {
+		if err := addDataToZip(zipWriter, ManifestDir+"/"+file.name, file.data, file.mode, modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addDataToZip adds generated content to the ZIP archive
+func addDataToZip(zipWriter *zip.Writer, zipPath string, data []byte, mode os.FileMode, modTime time.Time) error {
+	header := &zip.FileHeader{
+		Name:     zipPath,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	}
+	header.SetMode(mode)
+
+	writer, err := zipWriter.CreateHeader(header)
 	if err != nil {
 		return err
 	}
 
-	// Create ZIP header
-	header, err := zip.FileInfoHeader(info)
+	_, err = writer.Write(data)
+	return err
+}
+
+// addBlobToZip streams a single blob from the object store into the ZIP archive
+func addBlobToZip(zipWriter *zip.Writer, file *object.File, zipPath string, modTime time.Time) error {
+	mode, err := file.Mode.ToOSFileMode()
 	if err != nil {
 		return err
 	}
 
-	// Set the name in the ZIP (use forward slashes)
-	header.Name = strings.ReplaceAll(zipPath, "\\", "/")
-	header.Method = zip.Deflate
+	// Create ZIP header, using forward slashes for the name
+	header := &zip.FileHeader{
+		Name:     strings.ReplaceAll(zipPath, "\\", "/"),
+		Method:   zip.Deflate,
+		Modified: modTime,
+	}
+	header.SetMode(mode)
 
 	// Create writer for this file
 	writer, err := zipWriter.CreateHeader(header)
@@ -91,8 +168,14 @@ func addFileToZip(zipWriter *zip.Writer, filePath, zipPath string) error {
 		return err
 	}
 
-	// Copy file contents
-	_, err = io.Copy(writer, file)
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Copy blob contents
+	_, err = io.Copy(writer, reader)
 	return err
 }
 
@@ -101,4 +184,8 @@ type FileChange struct {
 	Path       string
 	ChangeType string
 	OldPath    string
+	Similarity int
+	Additions  int
+	Deletions  int
+	Binary     bool
 }
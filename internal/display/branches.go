@@ -28,52 +28,66 @@ func PrintBranches(branches []git.Branch) {
 	}
 	
 	if len(localBranches) > 0 {
-		fmt.Println("\n  Local branches:")
+		fmt.Fprintln(out, "\n  Local branches:")
 		for i, branch := range localBranches {
 			marker := "  "
 			if branch.IsHead {
 				marker = "→ "
 			}
 			
-			fmt.Printf("    %d. %s", i+1, marker)
+			fmt.Fprintf(out, "    %d. %s", i+1, marker)
 			Branch.Printf("%s", branch.Name)
 			
 			if branch.IsHead {
-				fmt.Printf(" (current HEAD)")
+				fmt.Fprintf(out, " (current HEAD)")
 			}
+			printDivergence(branch)
 			
 			if branch.LastCommit != nil {
 				timeAgo := timeAgoString(branch.LastCommit.Date)
-				fmt.Printf("\n       ")
+				fmt.Fprintf(out, "\n       ")
 				Commit.Printf("%s", branch.LastCommit.ShortHash)
-				fmt.Printf(" - %s - %s", timeAgo, branch.LastCommit.Author)
-				fmt.Printf("\n       %s\n", branch.LastCommit.Message)
+				fmt.Fprintf(out, " - %s - %s", timeAgo, branch.LastCommit.Author)
+				fmt.Fprintf(out, "\n       %s\n", branch.LastCommit.Message)
 			} else {
-				fmt.Println()
+				fmt.Fprintln(out)
 			}
 		}
 	}
 	
 	if len(remoteBranches) > 0 {
-		fmt.Println("\n  Remote branches:")
+		fmt.Fprintln(out, "\n  Remote branches:")
 		for i, branch := range remoteBranches {
-			fmt.Printf("    %d. ", i+1)
+			fmt.Fprintf(out, "    %d. ", i+1)
 			Branch.Printf("%s", branch.Name)
-			fmt.Printf(" (remote)")
+			fmt.Fprintf(out, " (remote)")
+			printDivergence(branch)
 			
 			if branch.LastCommit != nil {
 				timeAgo := timeAgoString(branch.LastCommit.Date)
-				fmt.Printf("\n       ")
+				fmt.Fprintf(out, "\n       ")
 				Commit.Printf("%s", branch.LastCommit.ShortHash)
-				fmt.Printf(" - %s - %s", timeAgo, branch.LastCommit.Author)
-				fmt.Printf("\n       %s\n", branch.LastCommit.Message)
+				fmt.Fprintf(out, " - %s - %s", timeAgo, branch.LastCommit.Author)
+				fmt.Fprintf(out, "\n       %s\n", branch.LastCommit.Message)
 			} else {
-				fmt.Println()
+				fmt.Fprintln(out)
 			}
 		}
 	}
 	
-	fmt.Println()
+	fmt.Fprintln(out)
+}
+
+// printDivergence prints how far a branch is ahead of and behind its base
+func printDivergence(branch git.Branch) {
+	if branch.Divergence == nil {
+		return
+	}
+	fmt.Fprintf(out, " [")
+	Added.Printf("↑%d", branch.Divergence.Ahead)
+	fmt.Fprintf(out, " ")
+	Deleted.Printf("↓%d", branch.Divergence.Behind)
+	fmt.Fprintf(out, " vs %s]", branch.Divergence.Base)
 }
 
 // FormatBranchOption formats a branch for selection
@@ -0,0 +1,60 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/githubCompare/internal/git"
+)
+
+// PrintMirrors displays the cached mirrors in cacheDir
+func PrintMirrors(cacheDir string, mirrors []git.Mirror) {
+	PrintSection(fmt.Sprintf("Cached Mirrors in %s", cacheDir))
+
+	if len(mirrors) == 0 {
+		PrintInfo("Cache is empty")
+		return
+	}
+
+	var total int64
+	for i, mirror := range mirrors {
+		fmt.Fprintf(out, "  %d. ", i+1)
+		Branch.Printf("%s\n", mirror.URL)
+		fmt.Fprintf(out, "     %s - last used %s\n", formatSize(mirror.Size), timeAgoString(mirror.LastUsed))
+		fmt.Fprintf(out, "     %s\n", mirror.Path)
+		total += mirror.Size
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  Total: ")
+	Count.Printf("%d mirrors, %s\n", len(mirrors), formatSize(total))
+	fmt.Fprintln(out)
+}
+
+// PrintRemovedMirrors reports which mirrors were removed from the cache
+func PrintRemovedMirrors(removed []git.Mirror) {
+	if len(removed) == 0 {
+		PrintInfo("No mirrors removed")
+		return
+	}
+
+	var total int64
+	for _, mirror := range removed {
+		Deleted.Printf("  - %s\n", mirror.URL)
+		total += mirror.Size
+	}
+	PrintSuccess(fmt.Sprintf("Removed %d mirrors, freed %s", len(removed), formatSize(total)))
+}
+
+// formatSize returns a human-readable byte size
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
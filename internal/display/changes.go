@@ -2,11 +2,25 @@ package display
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/fatih/color"
 	"github.com/githubCompare/internal/git"
 )
 
-// PrintChanges displays file changes in a formatted way
+// statBarWidth is the maximum width of the +/- bar in the diffstat
+const statBarWidth = 40
+
+// changeGroup is a section of the change list for one change type
+type changeGroup struct {
+	title   string
+	color   *color.Color
+	changes []git.FileChange
+}
+
+// PrintChanges displays file changes in a formatted way, with a diffstat
+// bar per file when line statistics are available
 func PrintChanges(changes []git.FileChange) {
 	if len(changes) == 0 {
 		PrintWarning("No changes found")
@@ -14,65 +28,168 @@ func PrintChanges(changes []git.FileChange) {
 	}
 
 	PrintSection(fmt.Sprintf("Changed Files (%d total)", len(changes)))
-	
+
 	// Group by change type
-	added := []git.FileChange{}
-	modified := []git.FileChange{}
-	deleted := []git.FileChange{}
-	renamed := []git.FileChange{}
-	
+	groups := map[string]*changeGroup{
+		"added":    {title: "➕ Added", color: Added},
+		"modified": {title: "✏️  Modified", color: Modified},
+		"renamed":  {title: "🔄 Renamed", color: Renamed},
+		"copied":   {title: "📋 Copied", color: Copied},
+		"deleted":  {title: "➖ Deleted", color: Deleted},
+	}
+	order := []string{"added", "modified", "renamed", "copied", "deleted"}
+
+	// Size the label column and the bar scale across all groups
+	labelWidth, maxLines := 0, 0
+	showStats := hasLineStats(changes)
 	for _, change := range changes {
-		switch change.ChangeType {
-		case "added":
-			added = append(added, change)
-		case "modified":
-			modified = append(modified, change)
-		case "deleted":
-			deleted = append(deleted, change)
-		case "renamed":
-			renamed = append(renamed, change)
+		if group, ok := groups[change.ChangeType]; ok {
+			group.changes = append(group.changes, change)
 		}
-	}
-	
-	if len(added) > 0 {
-		Added.Printf("\n  ➕ Added (%d):\n", len(added))
-		for _, change := range added {
-			File.Printf("      + %s\n", change.Path)
+		if width := utf8.RuneCountInString(changeLabel(change)); width > labelWidth {
+			labelWidth = width
 		}
-	}
-	
-	if len(modified) > 0 {
-		Modified.Printf("\n  ✏️  Modified (%d):\n", len(modified))
-		for _, change := range modified {
-			File.Printf("      ~ %s\n", change.Path)
+		if lines := change.Additions + change.Deletions; lines > maxLines {
+			maxLines = lines
 		}
 	}
-	
-	if len(renamed) > 0 {
-		Renamed.Printf("\n  🔄 Renamed (%d):\n", len(renamed))
-		for _, change := range renamed {
-			File.Printf("      %s → %s\n", change.OldPath, change.Path)
+	if labelWidth > 60 {
+		labelWidth = 60
+	}
+
+	for _, changeType := range order {
+		group := groups[changeType]
+		if len(group.changes) == 0 {
+			continue
 		}
+
+		group.color.Printf("\n  %s (%d):\n", group.title, len(group.changes))
+		for _, change := range group.changes {
+			label := changeLabel(change)
+			File.Printf("      %s", label)
+			if change.Similarity > 0 {
+				group.color.Printf(" (%d%%)", change.Similarity)
+			}
+			if showStats {
+				padding := labelWidth - utf8.RuneCountInString(label)
+				if change.Similarity > 0 {
+					padding -= len(fmt.Sprintf(" (%d%%)", change.Similarity))
+				}
+				if padding < 0 {
+					padding = 0
+				}
+				fmt.Fprintf(out, "%s | ", strings.Repeat(" ", padding))
+				printStat(change, maxLines)
+			}
+			fmt.Fprintln(out)
+		}
+	}
+
+	fmt.Fprintln(out)
+}
+
+// changeLabel returns the path text shown for a change
+func changeLabel(change git.FileChange) string {
+	switch change.ChangeType {
+	case "added":
+		return "+ " + change.Path
+	case "modified":
+		return "~ " + change.Path
+	case "deleted":
+		return "- " + change.Path
+	case "renamed":
+		return change.OldPath + " → " + change.Path
+	case "copied":
+		return change.OldPath + " ⇒ " + change.Path
+	}
+	return change.Path
+}
+
+// printStat prints the line counts and a +/- bar scaled against maxLines
+func printStat(change git.FileChange, maxLines int) {
+	if change.Binary {
+		Info.Printf("Bin %d -> %d bytes", change.OldSize, change.Size)
+		return
 	}
-	
-	if len(deleted) > 0 {
-		Deleted.Printf("\n  ➖ Deleted (%d):\n", len(deleted))
-		for _, change := range deleted {
-			File.Printf("      - %s\n", change.Path)
+
+	total := change.Additions + change.Deletions
+	fmt.Fprintf(out, "%5d ", total)
+
+	plus, minus := change.Additions, change.Deletions
+	if maxLines > statBarWidth {
+		// Scale down, but keep at least one character for any non-zero count
+		plus = scaleStat(change.Additions, maxLines)
+		minus = scaleStat(change.Deletions, maxLines)
+	}
+	Added.Print(strings.Repeat("+", plus))
+	Deleted.Print(strings.Repeat("-", minus))
+}
+
+// scaleStat scales a line count to the bar width
+func scaleStat(lines, maxLines int) int {
+	if lines == 0 {
+		return 0
+	}
+	scaled := lines * statBarWidth / maxLines
+	if scaled == 0 {
+		scaled = 1
+	}
+	return scaled
+}
+
+// hasLineStats reports whether line statistics were computed for changes
+func hasLineStats(changes []git.FileChange) bool {
+	for _, change := range changes {
+		if change.Additions > 0 || change.Deletions > 0 || change.Binary {
+			return true
 		}
 	}
-	
-	fmt.Println()
+	return false
 }
 
 // PrintSummary prints a summary of changes
-func PrintSummary(startRef, endRef string, changeCount int) {
+func PrintSummary(startRef, endRef string, changes []git.FileChange) {
 	PrintSection("Comparison Summary")
-	fmt.Printf("  Start: ")
+	fmt.Fprintf(out, "  Start: ")
 	Commit.Printf("%s\n", startRef)
-	fmt.Printf("  End:   ")
+	fmt.Fprintf(out, "  End:   ")
 	Commit.Printf("%s\n", endRef)
-	fmt.Printf("  Files: ")
-	Count.Printf("%d changed\n", changeCount)
-	fmt.Println()
+	fmt.Fprintf(out, "  Files: ")
+	Count.Printf("%d changed\n", len(changes))
+
+	if hasLineStats(changes) {
+		additions, deletions, binary := 0, 0, 0
+		for _, change := range changes {
+			additions += change.Additions
+			deletions += change.Deletions
+			if change.Binary {
+				binary++
+			}
+		}
+
+		fmt.Fprintf(out, "  Lines: ")
+		Added.Printf("+%d", additions)
+		fmt.Fprintf(out, " ")
+		Deleted.Printf("-%d", deletions)
+		if binary > 0 {
+			fmt.Fprintf(out, " (%d binary)", binary)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out)
+}
+
+// FormatChangeOption formats a changed file for selection
+func FormatChangeOption(change git.FileChange) string {
+	label := changeLabel(change)
+	if change.Similarity > 0 {
+		label += fmt.Sprintf(" (%d%%)", change.Similarity)
+	}
+	switch {
+	case change.Binary:
+		return fmt.Sprintf("%s  Bin %d -> %d bytes", label, change.OldSize, change.Size)
+	case change.Additions > 0 || change.Deletions > 0:
+		return fmt.Sprintf("%s  +%d -%d", label, change.Additions, change.Deletions)
+	}
+	return label
 }
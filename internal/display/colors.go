@@ -1,10 +1,18 @@
 package display
 
 import (
+	"fmt"
+	"os"
 	"strings"
+
 	"github.com/fatih/color"
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 )
 
+// out is where all human-readable output is written
+var out = os.Stdout
+
 var (
 	// Header colors
 	Header    = color.New(color.FgCyan, color.Bold)
@@ -24,8 +32,33 @@ var (
 	Modified  = color.New(color.FgYellow)
 	Deleted   = color.New(color.FgRed)
 	Renamed   = color.New(color.FgMagenta)
+	Copied    = color.New(color.FgBlue)
 )
 
+// SetOutput redirects all human-readable output, e.g. to os.Stderr when
+// stdout is reserved for machine-readable data
+func SetOutput(f *os.File) {
+	out = f
+	color.Output = colorable.NewColorable(f)
+	color.NoColor = os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" ||
+		(!isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// Output returns the file human-readable output is written to
+func Output() *os.File {
+	return out
+}
+
+// Printf prints formatted text to the human-readable output
+func Printf(format string, a ...interface{}) {
+	fmt.Fprintf(out, format, a...)
+}
+
+// Println prints a line to the human-readable output
+func Println(a ...interface{}) {
+	fmt.Fprintln(out, a...)
+}
+
 // PrintHeader prints a formatted header
 func PrintHeader(text string) {
 	Header.Println("\n" + text)
@@ -2,6 +2,7 @@ package display
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/githubCompare/internal/git"
@@ -26,16 +27,19 @@ func PrintCommits(commits []git.Commit, limit int) {
 		timeAgo := timeAgoString(commit.Date)
 		dateStr := commit.Date.Format("2006-01-02 15:04")
 		
-		fmt.Printf("  %d. ", i+1)
+		fmt.Fprintf(out, "  %d. ", i+1)
 		Commit.Printf("%s", commit.ShortHash)
-		fmt.Printf(" - %s (%s)", timeAgo, dateStr)
-		fmt.Printf(" - %s", commit.Author)
+		if len(commit.Tags) > 0 {
+			Branch.Printf(" (tag: %s)", strings.Join(commit.Tags, ", "))
+		}
+		fmt.Fprintf(out, " - %s (%s)", timeAgo, dateStr)
+		fmt.Fprintf(out, " - %s", commit.Author)
 		
 		// Show commit message more prominently
-		fmt.Printf("\n     ")
+		fmt.Fprintf(out, "\n     ")
 		Info.Printf("%s\n", commit.Message)
 		if i < displayCount-1 {
-			fmt.Println() // Only add blank line between items, not after last
+			fmt.Fprintln(out) // Only add blank line between items, not after last
 		}
 	}
 }
@@ -47,8 +51,12 @@ func FormatCommitOption(commit git.Commit, index int) string {
 	if len(message) > 55 {
 		message = message[:52] + "..."
 	}
+	hash := commit.ShortHash
+	if len(commit.Tags) > 0 {
+		hash += " (" + strings.Join(commit.Tags, ", ") + ")"
+	}
 	return fmt.Sprintf("%d. %s - %s - %s - %s", 
-		index+1, commit.ShortHash, timeAgo, commit.Author, message)
+		index+1, hash, timeAgo, commit.Author, message)
 }
 
 // timeAgoString returns a human-readable time ago string
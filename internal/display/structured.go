@@ -0,0 +1,51 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/githubCompare/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by PrintResult
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// ValidateFormat checks that format is a supported output format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q (expected text, json or yaml)", format)
+}
+
+// PrintResult writes result to w as JSON or YAML
+func PrintResult(w io.Writer, format string, result git.Result) error {
+	if result.Changes == nil {
+		result.Changes = []git.FileChange{}
+	}
+	if result.Commits == nil {
+		result.Commits = []git.Commit{}
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
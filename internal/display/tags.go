@@ -0,0 +1,57 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/githubCompare/internal/git"
+)
+
+// PrintTags displays tags in a formatted way
+func PrintTags(tags []git.Tag) {
+	if len(tags) == 0 {
+		PrintWarning("No tags found")
+		return
+	}
+
+	PrintSection(fmt.Sprintf("Tags (%d)", len(tags)))
+
+	for i, tag := range tags {
+		fmt.Fprintf(out, "    %d. ", i+1)
+		Branch.Printf("%s", tag.Name)
+		fmt.Fprintf(out, " → ")
+		Commit.Printf("%s", tag.Hash[:7])
+		fmt.Fprintf(out, " - %s (%s)", timeAgoString(tag.Date), tag.Date.Format("2006-01-02"))
+		if tag.Annotated {
+			fmt.Fprintf(out, " - %s", tag.Tagger)
+			if tag.Message != "" {
+				fmt.Fprintf(out, "\n       %s", firstLine(tag.Message))
+			}
+		}
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintln(out)
+}
+
+// FormatTagOption formats a tag for selection
+func FormatTagOption(tag git.Tag, index int) string {
+	option := fmt.Sprintf("%d. 🏷  %s - %s (%s)", index+1, tag.Name, tag.Hash[:7], timeAgoString(tag.Date))
+	if tag.Message != "" {
+		message := firstLine(tag.Message)
+		if len(message) > 50 {
+			message = message[:47] + "..."
+		}
+		option += ": " + message
+	}
+	return option
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	for i, r := range s {
+		if r == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
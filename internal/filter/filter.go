@@ -0,0 +1,167 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/githubCompare/internal/git"
+)
+
+// Filter selects changed paths using include and exclude globs.
+//
+// Patterns use doublestar syntax ("**" spans directories). A pattern without a
+// slash matches at any depth, and a pattern that matches a directory matches
+// everything below it, so "vendor", "vendor/" and "services/api/" all behave
+// like git pathspecs. Include patterns may also use the pathspec exclude magic
+// ":!pattern", ":^pattern" or ":(exclude)pattern".
+type Filter struct {
+	includes   []string
+	excludes   []string
+	exceptions []string // Paths matching these are never excluded ("!" lines in ignore files)
+}
+
+// New builds a filter from include and exclude patterns
+func New(includes, excludes []string) (*Filter, error) {
+	f := &Filter{}
+
+	for _, pattern := range includes {
+		if excluded, ok := cutExcludeMagic(pattern); ok {
+			if err := f.addPattern(&f.excludes, excluded); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := f.addPattern(&f.includes, pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pattern := range excludes {
+		if err := f.addPattern(&f.excludes, pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// AddIgnoreFile adds the patterns in an ignore file as excludes. Blank lines
+// and lines starting with "#" are skipped; lines starting with "!" re-include
+// paths that would otherwise be excluded.
+func (f *Filter) AddIgnoreFile(ignorePath string) error {
+	file, err := os.Open(ignorePath)
+	if err != nil {
+		return fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		target := &f.excludes
+		if strings.HasPrefix(line, "!") {
+			target = &f.exceptions
+			line = line[1:]
+		}
+		if err := f.addPattern(target, line); err != nil {
+			return fmt.Errorf("%s: %w", ignorePath, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter keeps every path
+func (f *Filter) IsEmpty() bool {
+	return len(f.includes) == 0 && len(f.excludes) == 0
+}
+
+// Match reports whether path is selected by the filter
+func (f *Filter) Match(filePath string) bool {
+	return f.included(filePath) && !f.excluded(filePath)
+}
+
+// Apply returns the selected changes. A rename or copy is kept when its old
+// or new path is included, unless its new path is excluded: the file is
+// archived at the new path, so moving it into an excluded directory drops it.
+func (f *Filter) Apply(changes []git.FileChange) []git.FileChange {
+	if f.IsEmpty() {
+		return changes
+	}
+
+	kept := []git.FileChange{}
+	for _, change := range changes {
+		included := f.included(change.Path) || (change.OldPath != "" && f.included(change.OldPath))
+		if included && !f.excluded(change.Path) {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+// included reports whether filePath matches the include patterns, if any
+func (f *Filter) included(filePath string) bool {
+	return len(f.includes) == 0 || matchAny(f.includes, filePath)
+}
+
+// excluded reports whether filePath matches an exclude pattern and no exception
+func (f *Filter) excluded(filePath string) bool {
+	return matchAny(f.excludes, filePath) && !matchAny(f.exceptions, filePath)
+}
+
+// addPattern normalizes and validates pattern before appending it to list
+func (f *Filter) addPattern(list *[]string, pattern string) error {
+	pattern = strings.TrimPrefix(pattern, ":(glob)")
+	pattern = strings.TrimPrefix(pattern, "./")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return nil
+	}
+
+	// Unanchored patterns match at any depth, as in .gitignore
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	if !doublestar.ValidatePattern(pattern) {
+		return fmt.Errorf("invalid pattern %q", pattern)
+	}
+
+	*list = append(*list, pattern)
+	return nil
+}
+
+// cutExcludeMagic strips git's pathspec exclude magic from pattern
+func cutExcludeMagic(pattern string) (string, bool) {
+	for _, prefix := range []string{":(exclude)", ":!", ":^"} {
+		if strings.HasPrefix(pattern, prefix) {
+			return pattern[len(prefix):], true
+		}
+	}
+	return pattern, false
+}
+
+// matchAny reports whether filePath, or any directory containing it, matches
+// one of the patterns
+func matchAny(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		for p := filePath; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if doublestar.MatchUnvalidated(pattern, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
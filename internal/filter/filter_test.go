@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/githubCompare/internal/git"
+)
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		path     string
+		want     bool
+	}{
+		{"no patterns", nil, nil, "any/file.go", true},
+		{"directory include", []string{"services/api/"}, nil, "services/api/handlers/user.go", true},
+		{"directory include miss", []string{"services/api/"}, nil, "services/web/main.go", false},
+		{"directory without slash", []string{"services/api"}, nil, "services/api/main.go", true},
+		{"extension at any depth", nil, []string{"*.lock"}, "deep/nested/yarn.lock", false},
+		{"excluded directory", nil, []string{"vendor/"}, "vendor/github.com/x/y.go", false},
+		{"nested excluded directory", nil, []string{"vendor"}, "lib/vendor/x.go", false},
+		{"doublestar", []string{"src/**/*.go"}, nil, "src/a/b/c.go", true},
+		{"doublestar miss", []string{"src/**/*.go"}, nil, "src/a/b/c.js", false},
+		{"include and exclude", []string{"src/"}, []string{"*_test.go"}, "src/a_test.go", false},
+		{"pathspec exclude magic", []string{"src/", ":!src/gen/"}, nil, "src/gen/x.go", false},
+		{"pathspec exclude magic keeps others", []string{"src/", ":(exclude)src/gen"}, nil, "src/x.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.includes, tt.excludes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := f.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	if _, err := New([]string{"src/[a-"}, nil); err == nil {
+		t.Errorf("New() should reject invalid patterns")
+	}
+}
+
+func TestAddIgnoreFile(t *testing.T) {
+	ignorePath := filepath.Join(t.TempDir(), ".compareignore")
+	content := "# generated files\n\n*.min.js\ndist/\n!dist/keep.txt\n"
+	if err := os.WriteFile(ignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	f, err := New(nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := f.AddIgnoreFile(ignorePath); err != nil {
+		t.Fatalf("AddIgnoreFile() error = %v", err)
+	}
+
+	tests := map[string]bool{
+		"app.min.js":    false,
+		"app.js":        true,
+		"dist/out.js":   false,
+		"dist/keep.txt": true,
+	}
+	for path, want := range tests {
+		if got := f.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFilterApplyRenames(t *testing.T) {
+	f, err := New([]string{"services/api/"}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	changes := []git.FileChange{
+		{Path: "services/api/a.go", ChangeType: "modified"},
+		{Path: "services/web/b.go", ChangeType: "modified"},
+		{Path: "shared/c.go", ChangeType: "renamed", OldPath: "services/api/c.go"},
+	}
+
+	kept := f.Apply(changes)
+	if len(kept) != 2 || kept[0].Path != "services/api/a.go" || kept[1].Path != "shared/c.go" {
+		t.Errorf("Apply() = %+v", kept)
+	}
+
+	// Exclusion follows the file to its new path
+	f, err = New(nil, []string{"vendor/**"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	changes = []git.FileChange{
+		{Path: "vendor/x.go", ChangeType: "renamed", OldPath: "src/x.go"},
+		{Path: "src/y.go", ChangeType: "renamed", OldPath: "vendor/y.go"},
+	}
+	kept = f.Apply(changes)
+	if len(kept) != 1 || kept[0].Path != "src/y.go" {
+		t.Errorf("Apply() with an excluded destination = %+v, want only src/y.go", kept)
+	}
+}
@@ -1,20 +1,23 @@
 package git
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // ListBranches lists all branches in the repository
-func ListBranches(repoPath string) ([]Branch, error) {
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open repository: %w", err)
-	}
+func ListBranches(ctx context.Context, r *Repository) ([]Branch, error) {
+	repo := r.repo
 
 	branches := []Branch{}
 	head, err := repo.Head()
@@ -29,37 +32,16 @@ func ListBranches(repoPath string) ([]Branch, error) {
 	}
 
 	err = branchIter.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if ref.Name().IsBranch() {
-			branch := Branch{
-				Name:     ref.Name().Short(),
-				IsRemote: false,
-				IsHead:   ref.Hash() == head.Hash(),
-			}
-			
-			// Get last commit for this branch
-			var commit *object.Commit
-			commit, err = repo.CommitObject(ref.Hash())
-			if err == nil {
-				message := strings.TrimSpace(commit.Message)
-				// Get first line of commit message
-				if idx := strings.Index(message, "\n"); idx > 0 {
-					message = message[:idx]
-				}
-				// Truncate if too long
-				if len(message) > 60 {
-					message = message[:57] + "..."
-				}
-				
-				branch.LastCommit = &Commit{
-					Hash:      commit.Hash.String(),
-					ShortHash: commit.Hash.String()[:7],
-					Message:   message,
-					Author:    commit.Author.Name,
-					Date:      commit.Author.When,
-				}
-			}
-			
-			branches = append(branches, branch)
+			branches = append(branches, Branch{
+				Name:       ref.Name().Short(),
+				IsRemote:   false,
+				IsHead:     ref.Hash() == head.Hash(),
+				LastCommit: lastCommit(repo, ref.Hash()),
+			})
 		}
 		return nil
 	})
@@ -67,64 +49,270 @@ func ListBranches(repoPath string) ([]Branch, error) {
 		return nil, fmt.Errorf("failed to process branches: %w", err)
 	}
 
-	// Get remote branches
-	remotes, err := repo.Remotes()
+	// Get remote-tracking branches from the local ref store, so that no
+	// network access is needed once the repository is on disk
+	refIter, err := repo.References()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get remotes: %w", err)
+		return nil, fmt.Errorf("failed to iterate references: %w", err)
 	}
 
-	for _, remote := range remotes {
-		refs, err := remote.List(&git.ListOptions{})
-		if err != nil {
-			continue // Skip if we can't list remote refs
-		}
-
-		for _, ref := range refs {
-			if ref.Name().IsBranch() {
-				branchName := strings.TrimPrefix(ref.Name().String(), "refs/remotes/"+remote.Config().Name+"/")
-				// Check if we already have this branch locally
-				exists := false
-				for _, b := range branches {
-					if b.Name == branchName {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					branch := Branch{
-						Name:     branchName,
-						IsRemote: true,
-						IsHead:   false,
-					}
-					
-					// Get last commit for remote branch
-					var commit *object.Commit
-					commit, err = repo.CommitObject(ref.Hash())
-					if err == nil {
-						message := strings.TrimSpace(commit.Message)
-						// Get first line of commit message
-						if idx := strings.Index(message, "\n"); idx > 0 {
-							message = message[:idx]
-						}
-						// Truncate if too long
-						if len(message) > 60 {
-							message = message[:57] + "..."
-						}
-						
-						branch.LastCommit = &Commit{
-							Hash:      commit.Hash.String(),
-							ShortHash: commit.Hash.String()[:7],
-							Message:   message,
-							Author:    commit.Author.Name,
-							Date:      commit.Author.When,
-						}
-					}
-					
-					branches = append(branches, branch)
-				}
+	err = refIter.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !ref.Name().IsRemote() || ref.Type() != plumbing.HashReference {
+			return nil // Skip symbolic refs such as origin/HEAD
+		}
+
+		// refs/remotes/<remote>/<branch> -> <branch>
+		branchName := strings.TrimPrefix(ref.Name().String(), "refs/remotes/")
+		if idx := strings.Index(branchName, "/"); idx >= 0 {
+			branchName = branchName[idx+1:]
+		}
+
+		// Check if we already have this branch locally
+		for _, b := range branches {
+			if b.Name == branchName {
+				return nil
 			}
 		}
+
+		branches = append(branches, Branch{
+			Name:       branchName,
+			IsRemote:   true,
+			IsHead:     false,
+			LastCommit: lastCommit(repo, ref.Hash()),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process remote branches: %w", err)
 	}
 
 	return branches, nil
 }
+
+// lastCommit loads the commit at hash for display, or nil if it is unavailable
+func lastCommit(repo *git.Repository, hash plumbing.Hash) *Commit {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil
+	}
+
+	message := strings.TrimSpace(commit.Message)
+	// Get first line of commit message
+	if idx := strings.Index(message, "\n"); idx > 0 {
+		message = message[:idx]
+	}
+	// Truncate if too long
+	if len(message) > 60 {
+		message = message[:57] + "..."
+	}
+
+	return &Commit{
+		Hash:      commit.Hash.String(),
+		ShortHash: commit.Hash.String()[:7],
+		Message:   message,
+		Author:    commit.Author.Name,
+		Date:      commit.Author.When,
+	}
+}
+
+// Branch sort keys accepted by SortBranches
+const (
+	SortByDate   = "date"
+	SortByName   = "name"
+	SortByAuthor = "author"
+)
+
+// SortBranches orders branches in place by last commit date (newest first),
+// name, or last commit author. Ties keep their name order.
+func SortBranches(branches []Branch, key string) error {
+	var less func(a, b Branch) bool
+	switch key {
+	case SortByDate:
+		less = func(a, b Branch) bool { return branchDate(a).After(branchDate(b)) }
+	case SortByName:
+		less = func(a, b Branch) bool { return false }
+	case SortByAuthor:
+		less = func(a, b Branch) bool { return branchAuthor(a) < branchAuthor(b) }
+	default:
+		return fmt.Errorf("unknown sort key %q (expected date, name or author)", key)
+	}
+
+	sort.SliceStable(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	sort.SliceStable(branches, func(i, j int) bool { return less(branches[i], branches[j]) })
+	return nil
+}
+
+// FilterBranches keeps the branches whose name matches pattern (a glob where
+// "**" spans "/", empty for all) and, when staleFor is positive, whose last
+// commit is older than staleFor before now
+func FilterBranches(branches []Branch, pattern string, staleFor time.Duration, now time.Time) ([]Branch, error) {
+	if pattern != "" && !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid branch pattern %q", pattern)
+	}
+
+	filtered := []Branch{}
+	for _, branch := range branches {
+		if pattern != "" {
+			if ok, _ := doublestar.Match(pattern, branch.Name); !ok {
+				continue
+			}
+		}
+		if staleFor > 0 && !branchDate(branch).Before(now.Add(-staleFor)) {
+			continue
+		}
+		filtered = append(filtered, branch)
+	}
+
+	return filtered, nil
+}
+
+// CountAheadBehind sets the divergence of every branch from baseRef: how many
+// commits each branch has that the base lacks (ahead) and the reverse (behind)
+func CountAheadBehind(ctx context.Context, r *Repository, baseRef string, branches []Branch) error {
+	repo := r.repo
+
+	baseHash, err := ResolveRef(repo, baseRef)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base branch %s: %w", baseRef, err)
+	}
+
+	for i := range branches {
+		if branches[i].LastCommit == nil {
+			continue
+		}
+		ahead, behind, err := countDivergence(ctx, repo, plumbing.NewHash(branches[i].LastCommit.Hash), *baseHash)
+		if err != nil {
+			return fmt.Errorf("failed to compare branch %s with %s: %w", branches[i].Name, baseRef, err)
+		}
+		branches[i].Divergence = &Divergence{Base: baseRef, Ahead: ahead, Behind: behind}
+	}
+
+	return nil
+}
+
+// Sides of a divergence walk a commit has been reached from
+const (
+	fromTip uint8 = 1 << iota
+	fromBase
+	fromBoth = fromTip | fromBase
+)
+
+// countDivergence counts the commits reachable only from tip (ahead) and only
+// from base (behind). Like "git rev-list --left-right --count", it walks both
+// histories newest first and stops once every commit left to visit is
+// reachable from both, so only the commits since the merge base are read.
+func countDivergence(ctx context.Context, repo *git.Repository, tip, base plumbing.Hash) (ahead, behind int, err error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reached := make(map[plumbing.Hash]uint8)
+	queue := &commitHeap{}
+	visit := func(hash plumbing.Hash, side uint8) error {
+		if reached[hash]&side == side {
+			return nil
+		}
+		commit, err := repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) && len(shallow) > 0 && hash != tip && hash != base {
+			return nil // Beyond the fetched history of a shallow clone
+		}
+		if err != nil {
+			return err
+		}
+		reached[hash] |= side
+		heap.Push(queue, commit)
+		return nil
+	}
+	if err := visit(tip, fromTip); err != nil {
+		return 0, 0, err
+	}
+	if err := visit(base, fromBase); err != nil {
+		return 0, 0, err
+	}
+
+	for queue.Len() > 0 && !reachedFromBoth(*queue, reached) {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		commit := heap.Pop(queue).(*object.Commit)
+		for _, parent := range commit.ParentHashes {
+			if err := visit(parent, reached[commit.Hash]); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	for _, side := range reached {
+		switch side {
+		case fromTip:
+			ahead++
+		case fromBase:
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// reachedFromBoth reports whether every queued commit is reachable from both
+// sides, so that nothing older can still be reachable from only one
+func reachedFromBoth(queue commitHeap, reached map[plumbing.Hash]uint8) bool {
+	for _, commit := range queue {
+		if reached[commit.Hash] != fromBoth {
+			return false
+		}
+	}
+	return true
+}
+
+// ancestors returns the set of commits reachable from hash, including itself.
+// In a shallow clone the walk stops where the fetched history ends.
+func ancestors(ctx context.Context, repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	pending := []plumbing.Hash{hash}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		next := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[next] {
+			continue
+		}
+
+		commit, err := repo.CommitObject(next)
+		if errors.Is(err, plumbing.ErrObjectNotFound) && len(shallow) > 0 && next != hash {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[next] = true
+		pending = append(pending, commit.ParentHashes...)
+	}
+	return seen, nil
+}
+
+// branchDate returns the last commit date of a branch, or the zero time
+func branchDate(branch Branch) time.Time {
+	if branch.LastCommit == nil {
+		return time.Time{}
+	}
+	return branch.LastCommit.Date
+}
+
+// branchAuthor returns the last commit author of a branch, or ""
+func branchAuthor(branch Branch) string {
+	if branch.LastCommit == nil {
+		return ""
+	}
+	return branch.LastCommit.Author
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+func branchNames(branches []Branch) string {
+	names := []string{}
+	for _, branch := range branches {
+		names = append(names, branch.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortAndFilterBranches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	branches := []Branch{
+		{Name: "main", LastCommit: &Commit{Author: "Carol", Date: now.Add(-time.Hour)}},
+		{Name: "feature/old", LastCommit: &Commit{Author: "Alice", Date: now.AddDate(0, -3, 0)}},
+		{Name: "feature/new", LastCommit: &Commit{Author: "Bob", Date: now.AddDate(0, 0, -2)}},
+		{Name: "detached"},
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{SortByDate, "main,feature/new,feature/old,detached"},
+		{SortByName, "detached,feature/new,feature/old,main"},
+		{SortByAuthor, "detached,feature/old,feature/new,main"},
+	}
+	for _, tt := range tests {
+		if err := SortBranches(branches, tt.key); err != nil {
+			t.Fatalf("SortBranches(%s) error = %v", tt.key, err)
+		}
+		if got := branchNames(branches); got != tt.want {
+			t.Errorf("SortBranches(%s) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+	if err := SortBranches(branches, "size"); err == nil {
+		t.Errorf("SortBranches() should reject an unknown key")
+	}
+
+	filtered, err := FilterBranches(branches, "feature/*", 30*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("FilterBranches() error = %v", err)
+	}
+	if got := branchNames(filtered); got != "feature/old" {
+		t.Errorf("FilterBranches() = %s, want feature/old", got)
+	}
+}
+
+func TestCountAheadBehind(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+
+	branches := []Branch{
+		{Name: "old", LastCommit: &Commit{Hash: hashes[0]}},
+		{Name: "tip", LastCommit: &Commit{Hash: hashes[2]}},
+	}
+	if err := CountAheadBehind(context.Background(), openTestRepo(t, dir), hashes[1], branches); err != nil {
+		t.Fatalf("CountAheadBehind() error = %v", err)
+	}
+
+	if d := branches[0].Divergence; d == nil || d.Ahead != 0 || d.Behind != 1 {
+		t.Errorf("divergence of old = %+v, want 0 ahead, 1 behind", d)
+	}
+	if d := branches[1].Divergence; d == nil || d.Ahead != 1 || d.Behind != 0 {
+		t.Errorf("divergence of tip = %+v, want 1 ahead, 0 behind", d)
+	}
+}
+
+func TestCountAheadBehindDiverged(t *testing.T) {
+	dir, main := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+	feature := testutil.ForkFixtureRepo(t, dir, "feature", main[0],
+		map[string]string{"b.txt": "one\n"},
+		map[string]string{"b.txt": "two\n"},
+		map[string]string{"b.txt": "three\n"},
+	)
+
+	branches := []Branch{
+		{Name: "feature", LastCommit: &Commit{Hash: feature[2]}},
+		{Name: "master", LastCommit: &Commit{Hash: main[2]}},
+	}
+	if err := CountAheadBehind(context.Background(), openTestRepo(t, dir), "master", branches); err != nil {
+		t.Fatalf("CountAheadBehind() error = %v", err)
+	}
+
+	if d := branches[0].Divergence; d == nil || d.Ahead != 3 || d.Behind != 2 {
+		t.Errorf("divergence of feature = %+v, want 3 ahead, 2 behind", d)
+	}
+	if d := branches[1].Divergence; d == nil || d.Ahead != 0 || d.Behind != 0 {
+		t.Errorf("divergence of master = %+v, want 0 ahead, 0 behind", d)
+	}
+}
@@ -0,0 +1,365 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/githubCompare/internal/utils"
+)
+
+// mirrorRefSpecs maps every remote branch and tag onto the same name in the
+// bare mirror, so refs resolve exactly as they do on the remote
+var mirrorRefSpecs = []config.RefSpec{
+	"+refs/heads/*:refs/heads/*",
+	"+refs/tags/*:refs/tags/*",
+}
+
+// MirrorOptions contains options for maintaining a cached bare mirror
+type MirrorOptions struct {
+	URL       string
+	AuthToken string
+	Path      string    // Directory holding the bare mirror
+	Progress  io.Writer // Receives fetch progress; nil to discard
+
+	// When Start and End are both set and the mirror is new or still
+	// shallow, only the refs they name are fetched, shallowly, deepening
+	// until the range between them is complete. Later fetches without a
+	// range complete the mirror.
+	Start     string
+	End       string
+	MergeBase bool // The range also needs the merge base of Start and End
+}
+
+// SyncMirror creates the bare mirror at opts.Path, or fetches incrementally
+// into it when it already exists, and returns the mirror path. Cancelling ctx,
+// e.g. at its deadline, aborts the fetch.
+func SyncMirror(ctx context.Context, opts MirrorOptions) (string, error) {
+	auth, err := getAuth(opts.URL, opts.AuthToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to set up authentication: %w", &AuthError{URL: opts.URL, Err: err})
+	}
+
+	repo, err := git.PlainOpen(opts.Path)
+	created := false
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = createMirror(opts.Path, opts.URL)
+		created = true
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open mirror: %w", err)
+	}
+	// The mirror is shared by every spelling of the URL, so follow this run's
+	if !created {
+		if err := setMirrorURL(repo, opts.URL); err != nil {
+			return "", err
+		}
+	}
+
+	if err := fetchMirror(ctx, repo, opts, auth, created); err != nil {
+		if created {
+			// Don't leave a half-populated mirror behind for the next run
+			os.RemoveAll(opts.Path)
+		}
+		return "", err
+	}
+
+	// Record the last use so prune can find stale mirrors
+	now := time.Now()
+	if err := os.Chtimes(opts.Path, now, now); err != nil {
+		return "", fmt.Errorf("failed to update mirror timestamp: %w", err)
+	}
+
+	return opts.Path, nil
+}
+
+// createMirror initializes an empty bare repository with an origin remote.
+// Credentials in url are not stored.
+func createMirror(path, url string) (*git.Repository, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+
+	repo, err := git.PlainInit(path, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mirror: %w", err)
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name:  git.DefaultRemoteName,
+		URLs:  []string{utils.StripCredentials(url)},
+		Fetch: mirrorRefSpecs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure mirror remote: %w", err)
+	}
+
+	return repo, nil
+}
+
+// setMirrorURL points the mirror's origin remote at url, without credentials
+func setMirrorURL(repo *git.Repository, url string) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read mirror config: %w", err)
+	}
+	cfg.Remotes[git.DefaultRemoteName] = &config.RemoteConfig{
+		Name:  git.DefaultRemoteName,
+		URLs:  []string{utils.StripCredentials(url)},
+		Fetch: mirrorRefSpecs,
+	}
+	if err := repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to update mirror remote: %w", err)
+	}
+	return nil
+}
+
+// fetchMirror brings the mirror up to date with the remote at opts.URL: new
+// objects are fetched, refs deleted on the remote are pruned and HEAD follows
+// the remote's default branch. A new or shallow mirror fetches only the range
+// of opts when it has one; a complete mirror always fetches every ref, as
+// deepening it would mark its history shallow. The URL is used as given,
+// credentials included, but never written to the mirror's config.
+func fetchMirror(ctx context.Context, repo *git.Repository, opts MirrorOptions, auth transport.AuthMethod, created bool) error {
+	remote := git.NewRemote(repo.Storer, &config.RemoteConfig{
+		Name:  git.DefaultRemoteName,
+		URLs:  []string{opts.URL},
+		Fetch: mirrorRefSpecs,
+	})
+
+	remoteRefs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return fmt.Errorf("failed to list remote references: %w", remoteError(opts.URL, err))
+	}
+
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return fmt.Errorf("failed to read shallow list: %w", err)
+	}
+	if opts.Start != "" && opts.End != "" && (created || len(shallow) > 0) {
+		err := fetchMirrorRange(ctx, repo, remote, remoteRefs, opts, auth)
+		if err == nil {
+			return pruneMirrorRefs(repo, remoteRefs)
+		}
+		if !errors.Is(err, errNeedFullClone) {
+			return err
+		}
+		if shallow, err = repo.Storer.Shallow(); err != nil {
+			return fmt.Errorf("failed to read shallow list: %w", err)
+		}
+	}
+
+	// Complete the history an earlier range fetch left shallow
+	depth := 0
+	if len(shallow) > 0 {
+		depth = fullDepth
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: mirrorRefSpecs,
+		Depth:    depth,
+		Auth:     auth,
+		Progress: opts.Progress,
+		Force:    true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch into mirror: %w", remoteError(opts.URL, err))
+	}
+	if len(shallow) > 0 {
+		if err := repo.Storer.SetShallow(nil); err != nil {
+			return fmt.Errorf("failed to update shallow list: %w", err)
+		}
+	}
+
+	return pruneMirrorRefs(repo, remoteRefs)
+}
+
+// fetchMirrorRange fetches only the refs the range of opts is based on into
+// the mirror, under their own names, as deep as the range needs. It returns
+// errNeedFullClone when the refs cannot be fetched on their own.
+func fetchMirrorRange(ctx context.Context, repo *git.Repository, remote *git.Remote, remoteRefs []*plumbing.Reference, opts MirrorOptions, auth transport.AuthMethod) error {
+	refSpecs, head, err := rangeRefSpecs(remoteRefs, opts.Start, opts.End, "refs/heads/")
+	if err != nil {
+		return err
+	}
+	if head != "" {
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, head)); err != nil {
+			return fmt.Errorf("failed to set mirror HEAD: %w", err)
+		}
+	}
+	return fetchRange(ctx, repo, remote, refSpecs, CloneOptions{
+		URL:       opts.URL,
+		Progress:  opts.Progress,
+		Start:     opts.Start,
+		End:       opts.End,
+		MergeBase: opts.MergeBase,
+	}, auth)
+}
+
+// pruneMirrorRefs removes branches and tags that no longer exist on the
+// remote and points HEAD at the remote's default branch
+func pruneMirrorRefs(repo *git.Repository, remoteRefs []*plumbing.Reference) error {
+	advertised := make(map[plumbing.ReferenceName]bool)
+	var remoteHead *plumbing.Reference
+	for _, ref := range remoteRefs {
+		advertised[ref.Name()] = true
+		if ref.Name() == plumbing.HEAD {
+			remoteHead = ref
+		}
+	}
+
+	refIter, err := repo.References()
+	if err != nil {
+		return fmt.Errorf("failed to iterate mirror references: %w", err)
+	}
+
+	stale := []plumbing.ReferenceName{}
+	err = refIter.ForEach(func(ref *plumbing.Reference) error {
+		if (ref.Name().IsBranch() || ref.Name().IsTag()) && !advertised[ref.Name()] {
+			stale = append(stale, ref.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to process mirror references: %w", err)
+	}
+
+	for _, name := range stale {
+		if err := repo.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("failed to prune %s: %w", name, err)
+		}
+	}
+
+	// Older servers advertise HEAD as a hash; fall back to a branch that matches it
+	if remoteHead == nil {
+		return nil
+	}
+	target := remoteHead.Target()
+	if remoteHead.Type() == plumbing.HashReference {
+		for _, ref := range remoteRefs {
+			if ref.Name().IsBranch() && ref.Hash() == remoteHead.Hash() {
+				target = ref.Name()
+				break
+			}
+		}
+	}
+	if target == "" {
+		return nil
+	}
+
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, target)
+	if err := repo.Storer.SetReference(head); err != nil {
+		return fmt.Errorf("failed to update mirror HEAD: %w", err)
+	}
+
+	return nil
+}
+
+// ListMirrors returns the cached mirrors found in cacheDir, most recently used first
+func ListMirrors(cacheDir string) ([]Mirror, error) {
+	entries, err := os.ReadDir(cacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Mirror{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	mirrors := []Mirror{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(cacheDir, entry.Name())
+		repo, err := git.PlainOpen(path)
+		if err != nil {
+			continue // Not a mirror
+		}
+
+		mirror := Mirror{Path: path}
+		if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+			mirror.URL = remote.Config().URLs[0]
+		}
+		if info, err := entry.Info(); err == nil {
+			mirror.LastUsed = info.ModTime()
+		}
+		mirror.Size = directorySize(path)
+
+		mirrors = append(mirrors, mirror)
+	}
+
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].LastUsed.After(mirrors[j].LastUsed)
+	})
+
+	return mirrors, nil
+}
+
+// PruneMirrors removes mirrors that have not been used within maxAge and
+// returns the mirrors that were removed
+func PruneMirrors(cacheDir string, maxAge time.Duration) ([]Mirror, error) {
+	mirrors, err := ListMirrors(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := []Mirror{}
+	for _, mirror := range mirrors {
+		if mirror.LastUsed.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(mirror.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove mirror %s: %w", mirror.Path, err)
+		}
+		removed = append(removed, mirror)
+	}
+
+	return removed, nil
+}
+
+// ClearMirrors removes every cached mirror and returns the mirrors that were removed
+func ClearMirrors(cacheDir string) ([]Mirror, error) {
+	mirrors, err := ListMirrors(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []Mirror{}
+	for _, mirror := range mirrors {
+		if err := os.RemoveAll(mirror.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove mirror %s: %w", mirror.Path, err)
+		}
+		removed = append(removed, mirror)
+	}
+
+	return removed, nil
+}
+
+// directorySize returns the total size of the regular files below path
+func directorySize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
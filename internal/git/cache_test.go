@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestSyncMirror(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+	)
+	source, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	feature := plumbing.NewBranchReferenceName("feature")
+	if err := source.Storer.SetReference(plumbing.NewHashReference(feature, plumbing.NewHash(hashes[0]))); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mirror")
+	if _, err := SyncMirror(ctx, MirrorOptions{URL: "file://" + dir, Path: path}); err != nil {
+		t.Fatalf("SyncMirror() creating error = %v", err)
+	}
+	if hash, err := GetCommitHash(openTestRepo(t, path), "feature"); err != nil || hash != hashes[0] {
+		t.Errorf("mirrored feature = %s, %v, want %s", hash, err, hashes[0])
+	}
+
+	// A branch deleted on the remote is pruned, and the URL of this sync is kept
+	if err := source.Storer.RemoveReference(feature); err != nil {
+		t.Fatalf("Failed to delete branch: %v", err)
+	}
+	if _, err := SyncMirror(ctx, MirrorOptions{URL: dir, Path: path}); err != nil {
+		t.Fatalf("SyncMirror() updating error = %v", err)
+	}
+	if _, err := GetCommitHash(openTestRepo(t, path), "feature"); err == nil {
+		t.Errorf("deleted branch feature is still in the mirror")
+	}
+	if url, err := RemoteURL(openTestRepo(t, path), "origin"); err != nil || url != dir {
+		t.Errorf("mirror remote URL = %q, %v, want %q", url, err, dir)
+	}
+}
+
+func TestPruneAndClearMirrors(t *testing.T) {
+	dir, _ := testutil.NewFixtureRepo(t, map[string]string{"a.txt": "one\n"})
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+
+	fresh := filepath.Join(cacheDir, "fresh")
+	stale := filepath.Join(cacheDir, "stale")
+	for _, path := range []string{fresh, stale} {
+		if _, err := SyncMirror(ctx, MirrorOptions{URL: "file://" + dir, Path: path}); err != nil {
+			t.Fatalf("SyncMirror(%s) error = %v", path, err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Failed to age mirror: %v", err)
+	}
+
+	removed, err := PruneMirrors(cacheDir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneMirrors() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].Path != stale {
+		t.Errorf("PruneMirrors() removed %v, want only %s", removed, stale)
+	}
+
+	removed, err = ClearMirrors(cacheDir)
+	if err != nil {
+		t.Fatalf("ClearMirrors() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].Path != fresh {
+		t.Errorf("ClearMirrors() removed %v, want only %s", removed, fresh)
+	}
+	if mirrors, _ := ListMirrors(cacheDir); len(mirrors) != 0 {
+		t.Errorf("ListMirrors() after ClearMirrors() = %v, want none", mirrors)
+	}
+}
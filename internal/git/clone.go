@@ -1,7 +1,10 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -18,10 +21,18 @@ type CloneOptions struct {
 	URL       string
 	AuthToken string
 	TempDir   string
+	Progress  io.Writer // Receives clone progress; nil to discard
+
+	// When Start and End are both set, only the refs they name are fetched,
+	// shallowly, deepening until the range between them is complete
+	Start     string
+	End       string
+	MergeBase bool // The range also needs the merge base of Start and End
 }
 
-// CloneRepository clones a Git repository to a temporary directory
-func CloneRepository(opts CloneOptions) (string, error) {
+// CloneRepository clones a Git repository to a temporary directory. Cancelling
+// ctx aborts the clone.
+func CloneRepository(ctx context.Context, opts CloneOptions) (string, error) {
 	// Create temp directory for this clone
 	clonePath := filepath.Join(opts.TempDir, "repo")
 	if err := os.MkdirAll(clonePath, 0755); err != nil {
@@ -31,32 +42,54 @@ func CloneRepository(opts CloneOptions) (string, error) {
 	// Configure clone options - fetch all branches
 	cloneOpts := &git.CloneOptions{
 		URL:               opts.URL,
-		Progress:         os.Stdout,
+		Progress:         opts.Progress,
 		SingleBranch:     false,
 		Depth:            0, // Full clone to get all branches
 		RecurseSubmodules: git.NoRecurseSubmodules,
+		NoCheckout:        true, // Archives are built from commit objects, not the worktree
 	}
 
 	// Set up authentication
 	auth, err := getAuth(opts.URL, opts.AuthToken)
 	if err != nil {
-		return "", fmt.Errorf("failed to set up authentication: %w", err)
+		return "", fmt.Errorf("failed to set up authentication: %w", &AuthError{URL: opts.URL, Err: err})
 	}
 	if auth != nil {
 		cloneOpts.Auth = auth
 	}
 
+	// Fetch just the range when the refs allow it, else start over with a full clone
+	if opts.Start != "" && opts.End != "" {
+		repo, err := git.PlainInit(clonePath, true)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize repository: %w", err)
+		}
+		err = cloneRange(ctx, repo, opts, auth)
+		if err == nil {
+			return clonePath, nil
+		}
+		if !errors.Is(err, errNeedFullClone) {
+			return "", err
+		}
+		if err := os.RemoveAll(clonePath); err != nil {
+			return "", fmt.Errorf("failed to reset clone directory: %w", err)
+		}
+		if err := os.MkdirAll(clonePath, 0755); err != nil {
+			return "", fmt.Errorf("failed to create temp directory: %w", err)
+		}
+	}
+
 	// Clone the repository
-	repo, err := git.PlainClone(clonePath, false, cloneOpts)
+	repo, err := git.PlainCloneContext(ctx, clonePath, false, cloneOpts)
 	if err != nil {
-		return "", fmt.Errorf("failed to clone repository: %w", err)
+		return "", fmt.Errorf("failed to clone repository: %w", remoteError(opts.URL, err))
 	}
 
 	// Fetch all remote branches
 	remotes, err := repo.Remotes()
 	if err == nil && len(remotes) > 0 {
 		remote := remotes[0]
-		err = remote.Fetch(&git.FetchOptions{
+		err = remote.FetchContext(ctx, &git.FetchOptions{
 			RefSpecs: []config.RefSpec{"refs/heads/*:refs/remotes/origin/*"},
 		})
 		// Ignore fetch errors - branches might already be fetched
@@ -1,29 +1,35 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // ListCommits lists commits for a given reference
-func ListCommits(repoPath, ref string, limit int) ([]Commit, error) {
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open repository: %w", err)
-	}
+func ListCommits(ctx context.Context, r *Repository, ref string, limit int) ([]Commit, error) {
+	return ListCommitsPage(ctx, r, ref, 0, limit)
+}
+
+// ListCommitsPage lists up to limit commits for a given reference after
+// skipping the first skip, so long histories can be loaded a page at a time.
+// Cancelling ctx stops the walk.
+func ListCommitsPage(ctx context.Context, r *Repository, ref string, skip, limit int) ([]Commit, error) {
+	repo := r.repo
 
 	// Resolve the reference
-	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	hash, err := ResolveRef(repo, ref)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
 	}
 
 	commits := []Commit{}
 	count := 0
+	skipped := 0
 
 	// Iterate through commit history using Log
 	commitIter, err := repo.Log(&git.LogOptions{From: *hash})
@@ -33,6 +39,13 @@ func ListCommits(repoPath, ref string, limit int) ([]Commit, error) {
 	defer commitIter.Close()
 
 	err = commitIter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if skipped < skip {
+			skipped++
+			return nil
+		}
 		if limit > 0 && count >= limit {
 			return fmt.Errorf("limit reached")
 		}
@@ -59,5 +72,118 @@ func ListCommits(repoPath, ref string, limit int) ([]Commit, error) {
 		return nil, fmt.Errorf("failed to iterate commits: %w", err)
 	}
 
+	labelTags(r, commits)
+	return commits, nil
+}
+
+// ListCommitRange lists the commits reachable from endRef but not from
+// startRef, newest first, like "git log startRef..endRef"
+func ListCommitRange(ctx context.Context, r *Repository, startRef, endRef string) ([]Commit, error) {
+	repo := r.repo
+
+	startHash, err := ResolveRef(repo, startRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve start reference %s: %w", startRef, err)
+	}
+	endHash, err := ResolveRef(repo, endRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve end reference %s: %w", endRef, err)
+	}
+
+	// Everything reachable from start is excluded from the range
+	excluded, err := ancestors(ctx, repo, *startHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+	}
+
+	endCommit, err := repo.CommitObject(*endHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get end commit: %w", err)
+	}
+
+	commits := []Commit{}
+	endIter := object.NewCommitIterCTime(endCommit, excluded, nil)
+	defer endIter.Close()
+	err = endIter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		commits = append(commits, Commit{
+			Hash:      c.Hash.String(),
+			ShortHash: c.Hash.String()[:7],
+			Message:   strings.TrimSpace(subject),
+			Body:      strings.TrimSpace(body),
+			Author:    c.Author.Name,
+			Date:      c.Author.When,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+	}
+
+	labelTags(r, commits)
 	return commits, nil
 }
+
+// CommitAtDate returns the commit ref pointed to at the given date: the newest
+// commit on its first-parent history committed at or before it. Commits
+// merged in from other branches are skipped, so the result is always a state
+// the branch itself was in. The committer date decides the cutoff, as it is
+// when the commit reached the branch; Date is the author date, like every
+// other listed commit.
+func CommitAtDate(r *Repository, ref string, date time.Time) (*Commit, error) {
+	repo := r.repo
+
+	hash, err := ResolveRef(repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
+	}
+	tip, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", ref, err)
+	}
+
+	c, err := firstParentAt(tip, ref, date)
+	if err != nil {
+		return nil, err
+	}
+	return &Commit{
+		Hash:      c.Hash.String(),
+		ShortHash: c.Hash.String()[:7],
+		Message:   firstLine(c.Message),
+		Author:    c.Author.Name,
+		Date:      c.Author.When,
+	}, nil
+}
+
+// IsAncestor reports whether ancestorRef is reachable from descendantRef. A
+// commit counts as its own ancestor.
+func IsAncestor(r *Repository, ancestorRef, descendantRef string) (bool, error) {
+	repo := r.repo
+
+	ancestorHash, err := ResolveRef(repo, ancestorRef)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve reference %s: %w", ancestorRef, err)
+	}
+	descendantHash, err := ResolveRef(repo, descendantRef)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve reference %s: %w", descendantRef, err)
+	}
+
+	ancestor, err := repo.CommitObject(*ancestorHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit %s: %w", ancestorRef, err)
+	}
+	descendant, err := repo.CommitObject(*descendantHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit %s: %w", descendantRef, err)
+	}
+
+	isAncestor, err := ancestor.IsAncestor(descendant)
+	if err != nil {
+		return false, fmt.Errorf("failed to walk history: %w", err)
+	}
+	return isAncestor, nil
+}
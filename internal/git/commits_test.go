@@ -0,0 +1,143 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+func TestListCommitRange(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+	repo := openTestRepo(t, dir)
+
+	commits, err := ListCommitRange(context.Background(), repo, hashes[0], hashes[2])
+	if err != nil {
+		t.Fatalf("ListCommitRange() error = %v", err)
+	}
+
+	want := []string{hashes[2], hashes[1]}
+	if len(commits) != len(want) {
+		t.Fatalf("ListCommitRange() returned %d commits, want %d", len(commits), len(want))
+	}
+	for i, commit := range commits {
+		if commit.Hash != want[i] {
+			t.Errorf("commit %d = %s, want %s", i, commit.Hash, want[i])
+		}
+	}
+
+	commits, err = ListCommitRange(context.Background(), repo, hashes[2], hashes[0])
+	if err != nil {
+		t.Fatalf("ListCommitRange() error = %v", err)
+	}
+	if len(commits) != 0 {
+		t.Errorf("ListCommitRange() of an ancestor returned %d commits, want 0", len(commits))
+	}
+}
+
+func TestCommitAtDate(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+	repo := openTestRepo(t, dir)
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{first, hashes[0]},
+		{first.Add(90 * time.Minute), hashes[1]},
+		{first.Add(2 * time.Hour), hashes[2]},
+		{first.AddDate(1, 0, 0), hashes[2]},
+	}
+	for _, tt := range tests {
+		got, err := CommitAtDate(repo, "HEAD", tt.date)
+		if err != nil {
+			t.Errorf("CommitAtDate(%v) error = %v", tt.date, err)
+			continue
+		}
+		if got.Hash != tt.want {
+			t.Errorf("CommitAtDate(%v) = %s, want %s", tt.date, got.Hash, tt.want)
+		}
+	}
+
+	if _, err := CommitAtDate(repo, "HEAD", first.Add(-time.Minute)); err == nil {
+		t.Errorf("CommitAtDate() before the first commit should fail")
+	}
+}
+
+func TestListCommitsPage(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+	repo := openTestRepo(t, dir)
+
+	tests := []struct {
+		skip, limit int
+		want        []string
+	}{
+		{0, 2, []string{hashes[2], hashes[1]}},
+		{2, 2, []string{hashes[0]}},
+		{3, 2, nil},
+		{1, 0, []string{hashes[1], hashes[0]}},
+	}
+	for _, tt := range tests {
+		commits, err := ListCommitsPage(context.Background(), repo, "HEAD", tt.skip, tt.limit)
+		if err != nil {
+			t.Errorf("ListCommitsPage(%d, %d) error = %v", tt.skip, tt.limit, err)
+			continue
+		}
+		got := []string{}
+		for _, c := range commits {
+			got = append(got, c.Hash)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ListCommitsPage(%d, %d) = %v, want %v", tt.skip, tt.limit, got, tt.want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ListCommitsPage(ctx, repo, "HEAD", 0, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListCommitsPage() with a cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+	)
+	repo := openTestRepo(t, dir)
+
+	tests := []struct {
+		ancestor, descendant string
+		want                 bool
+	}{
+		{hashes[0], hashes[1], true},
+		{hashes[1], hashes[0], false},
+		{hashes[1], hashes[1], true},
+	}
+	for _, tt := range tests {
+		got, err := IsAncestor(repo, tt.ancestor, tt.descendant)
+		if err != nil {
+			t.Errorf("IsAncestor(%s, %s) error = %v", tt.ancestor[:7], tt.descendant[:7], err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", tt.ancestor[:7], tt.descendant[:7], got, tt.want)
+		}
+	}
+}
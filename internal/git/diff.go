@@ -1,96 +1,303 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
+// DefaultDiffOptions matches git's defaults: renames at 50% similarity, no
+// copies, with line statistics
+var DefaultDiffOptions = DiffOptions{
+	DetectRenames:   true,
+	RenameThreshold: 50,
+	LineStats:       true,
+}
+
 // GetChangedFiles compares two references and returns all changed files
-func GetChangedFiles(repoPath, startRef, endRef string) ([]FileChange, error) {
-	repo, err := git.PlainOpen(repoPath)
+func GetChangedFiles(ctx context.Context, r *Repository, startRef, endRef string, opts DiffOptions) ([]FileChange, error) {
+	repo := r.repo
+
+	changes, startTree, err := diffRefs(ctx, repo, startRef, endRef, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var copies map[*object.Change]copySource
+	if opts.DetectCopies {
+		copies, err = detectCopies(repo, startTree, changes, opts.RenameThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect copies: %w", err)
+		}
+	}
+
+	fileChanges := []FileChange{}
+
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fileChange := FileChange{}
+
+		// Determine change type
+		if change.From.Name == "" {
+			fileChange.ChangeType = "added"
+			fileChange.Path = change.To.Name
+		} else if change.To.Name == "" {
+			fileChange.ChangeType = "deleted"
+			fileChange.Path = change.From.Name
+		} else if change.From.Name != change.To.Name {
+			fileChange.ChangeType = "renamed"
+			fileChange.Path = change.To.Name
+			fileChange.OldPath = change.From.Name
+			fileChange.Similarity, err = blobSimilarity(repo, change.From.Name, change.From.TreeEntry.Hash, change.To.Name, change.To.TreeEntry.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to score rename of %s: %w", change.From.Name, err)
+			}
+		} else {
+			fileChange.ChangeType = "modified"
+			fileChange.Path = change.To.Name
+		}
+
+		// Additions that duplicate an existing file are reported as copies
+		if source, ok := copies[change]; ok {
+			fileChange.ChangeType = "copied"
+			fileChange.OldPath = source.path
+			fileChange.Similarity = source.similarity
+		}
+
+		// Record sizes and modes of both sides
+		if change.From.Name != "" {
+			fileChange.OldMode = change.From.TreeEntry.Mode.String()
+			fileChange.OldSize = blobSize(repo, change.From.TreeEntry.Hash)
+		}
+		if change.To.Name != "" {
+			fileChange.Mode = change.To.TreeEntry.Mode.String()
+			fileChange.Size = blobSize(repo, change.To.TreeEntry.Hash)
+		}
+
+		if opts.LineStats {
+			if err := countLines(ctx, change, &fileChange); err != nil {
+				return nil, fmt.Errorf("failed to count lines in %s: %w", fileChange.Path, ctxErr(ctx, err))
+			}
+		}
+
+		fileChanges = append(fileChanges, fileChange)
+	}
+
+	return fileChanges, nil
+}
+
+// countLines fills in the added and removed line counts of fileChange from
+// the content diff of change, or marks it binary
+func countLines(ctx context.Context, change *object.Change, fileChange *FileChange) error {
+	// Submodule entries point at commits and have no lines to count
+	if change.From.TreeEntry.Mode == filemode.Submodule || change.To.TreeEntry.Mode == filemode.Submodule {
+		return nil
+	}
+
+	patch, err := change.PatchContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open repository: %w", err)
+		return err
+	}
+
+	for _, filePatch := range patch.FilePatches() {
+		if filePatch.IsBinary() {
+			fileChange.Binary = true
+			continue
+		}
+		for _, chunk := range filePatch.Chunks() {
+			switch chunk.Type() {
+			case fdiff.Add:
+				fileChange.Additions += lineCount(chunk.Content())
+			case fdiff.Delete:
+				fileChange.Deletions += lineCount(chunk.Content())
+			}
+		}
 	}
 
+	return nil
+}
+
+// lineCount counts lines in s, including a final line without a newline
+func lineCount(s string) int {
+	count := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		count++
+	}
+	return count
+}
+
+// blobSize returns the size of a blob, or 0 for entries that are not blobs
+// such as submodule commits
+func blobSize(repo *git.Repository, hash plumbing.Hash) int64 {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return 0
+	}
+	return blob.Size
+}
+
+// copySource records the file an added path was copied from
+type copySource struct {
+	path       string
+	similarity int
+}
+
+// detectCopies finds added files that are copies of files in the start tree.
+// Like "git diff -C", exact copies are matched against the whole start tree,
+// while similar (edited) copies only consider files modified in the same range
+// as sources, which keeps the number of comparisons small.
+func detectCopies(repo *git.Repository, startTree *object.Tree, changes object.Changes, threshold int) (map[*object.Change]copySource, error) {
+	added := []*object.Change{}
+	sources := []object.ChangeEntry{}
+	for _, change := range changes {
+		switch {
+		case change.From.Name == "" && change.To.TreeEntry.Mode.IsFile():
+			added = append(added, change)
+		case change.From.Name == change.To.Name:
+			sources = append(sources, change.From)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	// Index every blob in the start tree for exact matches
+	byHash := make(map[plumbing.Hash]string)
+	err := startTree.Files().ForEach(func(f *object.File) error {
+		if _, ok := byHash[f.Hash]; !ok {
+			byHash[f.Hash] = f.Name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make(map[*object.Change]copySource)
+	sourceIdx := make(map[plumbing.Hash]*similarityIndex)
+	for _, change := range added {
+		if path, ok := byHash[change.To.TreeEntry.Hash]; ok {
+			copies[change] = copySource{path: path, similarity: 100}
+			continue
+		}
+
+		var targetIdx *similarityIndex
+		best := copySource{}
+		for _, source := range sources {
+			if source.TreeEntry.Mode != filemode.Regular && source.TreeEntry.Mode != filemode.Executable {
+				continue
+			}
+			if targetIdx == nil {
+				if targetIdx, err = blobSimilarityIndex(repo, change.To.TreeEntry.Hash); err != nil {
+					return nil, err
+				}
+			}
+			idx, ok := sourceIdx[source.TreeEntry.Hash]
+			if !ok {
+				if idx, err = blobSimilarityIndex(repo, source.TreeEntry.Hash); err != nil {
+					return nil, err
+				}
+				sourceIdx[source.TreeEntry.Hash] = idx
+			}
+
+			if score := renameScore(source.Name, idx, change.To.Name, targetIdx); score >= threshold && score > best.similarity {
+				best = copySource{path: source.Name, similarity: score}
+			}
+		}
+		if best.path != "" {
+			copies[change] = best
+		}
+	}
+
+	return copies, nil
+}
+
+// diffRefs resolves both references and returns the tree diff between them,
+// along with the start tree. When include is not nil, only changes with an
+// old or new path it accepts are kept, before renames are paired, so a rename
+// is only found when include accepts both of its paths.
+func diffRefs(ctx context.Context, repo *git.Repository, startRef, endRef string, opts DiffOptions, include func(path string) bool) (object.Changes, *object.Tree, error) {
 	// Resolve start reference (try multiple formats)
 	startHash, err := ResolveRef(repo, startRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve start reference %s: %w", startRef, err)
+		return nil, nil, fmt.Errorf("failed to resolve start reference %s: %w", startRef, err)
 	}
 
 	// Resolve end reference (try multiple formats)
 	endHash, err := ResolveRef(repo, endRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve end reference %s: %w", endRef, err)
+		return nil, nil, fmt.Errorf("failed to resolve end reference %s: %w", endRef, err)
 	}
 
 	// Get commit objects
 	startCommit, err := repo.CommitObject(*startHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get start commit: %w", err)
+		return nil, nil, fmt.Errorf("failed to get start commit: %w", err)
 	}
 
 	endCommit, err := repo.CommitObject(*endHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get end commit: %w", err)
+		return nil, nil, fmt.Errorf("failed to get end commit: %w", err)
 	}
 
 	// Get trees
 	startTree, err := startCommit.Tree()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get start tree: %w", err)
+		return nil, nil, fmt.Errorf("failed to get start tree: %w", err)
 	}
 
 	endTree, err := endCommit.Tree()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get end tree: %w", err)
+		return nil, nil, fmt.Errorf("failed to get end tree: %w", err)
 	}
 
-	// Get diff
-	changes, err := object.DiffTree(startTree, endTree)
-	if err != nil {
-		return nil, fmt.Errorf("failed to diff trees: %w", err)
+	// Get diff, pairing deletions and additions into renames if requested
+	diffOpts := &object.DiffTreeOptions{
+		DetectRenames: opts.DetectRenames,
+		RenameScore:   uint(opts.RenameThreshold),
+	}
+	if include == nil {
+		changes, err := object.DiffTreeWithOptions(ctx, startTree, endTree, diffOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to diff trees: %w", ctxErr(ctx, err))
+		}
+		return changes, startTree, nil
 	}
 
-	fileChanges := []FileChange{}
-
+	// Filter first so renames are only scored among the included paths
+	changes, err := object.DiffTreeWithOptions(ctx, startTree, endTree, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to diff trees: %w", ctxErr(ctx, err))
+	}
+	included := object.Changes{}
 	for _, change := range changes {
-		fileChange := FileChange{}
-
-		// Determine change type
-		if change.From.Name == "" {
-			fileChange.ChangeType = "added"
-			fileChange.Path = change.To.Name
-		} else if change.To.Name == "" {
-			fileChange.ChangeType = "deleted"
-			fileChange.Path = change.From.Name
-		} else if change.From.Name != change.To.Name {
-			fileChange.ChangeType = "renamed"
-			fileChange.Path = change.To.Name
-			fileChange.OldPath = change.From.Name
-		} else {
-			fileChange.ChangeType = "modified"
-			fileChange.Path = change.To.Name
+		if includesChange(include, change) {
+			included = append(included, change)
+		}
+	}
+	if opts.DetectRenames {
+		if included, err = object.DetectRenames(included, diffOpts); err != nil {
+			return nil, nil, fmt.Errorf("failed to detect renames: %w", err)
 		}
-
-		fileChanges = append(fileChanges, fileChange)
 	}
 
-	return fileChanges, nil
+	return included, startTree, nil
 }
 
 // ValidateRefs validates that both references exist
-func ValidateRefs(repoPath, startRef, endRef string) error {
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
+func ValidateRefs(r *Repository, startRef, endRef string) error {
+	repo := r.repo
 
 	// Resolve references - just check they exist (try multiple formats)
-	_, err = ResolveRef(repo, startRef)
+	_, err := ResolveRef(repo, startRef)
 	if err != nil {
 		return fmt.Errorf("start reference %s not found: %w", startRef, err)
 	}
@@ -103,14 +310,28 @@ func ValidateRefs(repoPath, startRef, endRef string) error {
 	return nil
 }
 
-// GetCommitHash returns the full hash for a reference
-func GetCommitHash(repoPath, ref string) (string, error) {
-	repo, err := git.PlainOpen(repoPath)
+// GetCommit resolves a reference and returns its commit object
+func GetCommit(r *Repository, ref string) (*object.Commit, error) {
+	repo := r.repo
+
+	hash, err := ResolveRef(repo, ref)
 	if err != nil {
-		return "", fmt.Errorf("failed to open repository: %w", err)
+		return nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
 	}
 
-	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash.String(), err)
+	}
+
+	return commit, nil
+}
+
+// GetCommitHash returns the full hash for a reference
+func GetCommitHash(r *Repository, ref string) (string, error) {
+	repo := r.repo
+
+	hash, err := ResolveRef(repo, ref)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve reference %s: %w", ref, err)
 	}
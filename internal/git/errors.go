@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// The error types below classify failures so callers can tell them apart with
+// errors.As, e.g. to choose an exit code. They are usually returned wrapped
+// in "failed to ..." context.
+
+// AuthError reports that a remote required credentials or rejected them
+type AuthError struct {
+	URL string
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for %s: %v", e.URL, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// NetworkError reports that a remote could not be reached
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("could not reach %s: %v", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// NotFoundError reports a missing repository, reference, tag or commit
+type NotFoundError struct {
+	Kind        string   // "repository", "reference", "tag" or "commit"
+	Name        string   // What was looked for
+	Suggestions []string // Similar existing names, if any
+}
+
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("%s not found: %s", e.Kind, e.Name)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+// AmbiguousRefError reports an abbreviated hash that matches several commits
+type AmbiguousRefError struct {
+	Ref        string
+	Candidates []string // One "<hash> <date> <subject>" line per commit
+}
+
+func (e *AmbiguousRefError) Error() string {
+	return fmt.Sprintf("short hash %s is ambiguous; candidates:\n  %s", e.Ref, strings.Join(e.Candidates, "\n  "))
+}
+
+// EmptyRangeError reports that two references have no changes between them
+type EmptyRangeError struct {
+	Start string
+	End   string
+}
+
+func (e *EmptyRangeError) Error() string {
+	return fmt.Sprintf("no files changed between %s and %s", e.Start, e.End)
+}
+
+// ctxErr returns the error of a cancelled or expired ctx in place of err, as
+// go-git reports cancellation with errors of its own
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// remoteError classifies an error from talking to the remote at url as an
+// AuthError, NotFoundError or NetworkError, returning other errors unchanged
+func remoteError(url string, err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod),
+		strings.Contains(err.Error(), "unable to authenticate"):
+		return &AuthError{URL: url, Err: err}
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return &NotFoundError{Kind: "repository", Name: url}
+	case errors.As(err, &netErr):
+		return &NetworkError{URL: url, Err: err}
+	}
+	return err
+}
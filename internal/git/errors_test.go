@@ -0,0 +1,68 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+func TestRemoteError(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "example.invalid"}
+
+	tests := []struct {
+		name string
+		err  error
+		want interface{}
+	}{
+		{"auth required", transport.ErrAuthenticationRequired, &AuthError{}},
+		{"auth failed", fmt.Errorf("wrapped: %w", transport.ErrAuthorizationFailed), &AuthError{}},
+		{"ssh", errors.New("ssh: handshake failed: ssh: unable to authenticate"), &AuthError{}},
+		{"missing repository", transport.ErrRepositoryNotFound, &NotFoundError{}},
+		{"dns", &net.OpError{Op: "dial", Err: dnsErr}, &NetworkError{}},
+	}
+
+	for _, tt := range tests {
+		err := remoteError("https://example.invalid/repo.git", tt.err)
+		var ok bool
+		switch tt.want.(type) {
+		case *AuthError:
+			var target *AuthError
+			ok = errors.As(err, &target)
+		case *NotFoundError:
+			var target *NotFoundError
+			ok = errors.As(err, &target) && target.Kind == "repository"
+		case *NetworkError:
+			var target *NetworkError
+			ok = errors.As(err, &target)
+		}
+		if !ok {
+			t.Errorf("%s: remoteError() = %T (%v), want %T", tt.name, err, err, tt.want)
+		}
+	}
+
+	plain := errors.New("something else")
+	if err := remoteError("https://example.invalid/repo.git", plain); err != plain {
+		t.Errorf("remoteError() should leave unclassified errors unchanged, got %v", err)
+	}
+}
+
+func TestResolveRefErrorTypes(t *testing.T) {
+	dir, _ := testutil.NewFixtureRepo(t, map[string]string{"a.txt": "one\n"})
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+
+	for _, ref := range []string{"missing", "HEAD~3", "0123456789012345678901234567890123456789"} {
+		_, err := ResolveRef(repo, ref)
+		var notFound *NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("ResolveRef(%q) error = %v, want a NotFoundError", ref, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// OpenLocalRepository locates an existing repository on disk and returns the
+// path that the rest of the package should open. The given path may point
+// anywhere inside a worktree or at a bare repository.
+func OpenLocalRepository(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+
+	repo, err := git.PlainOpenWithOptions(absPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return "", &NotFoundError{Kind: "repository", Name: absPath}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository at %s: %w", absPath, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if errors.Is(err, git.ErrIsBareRepository) {
+		return absPath, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	return worktree.Filesystem.Root(), nil
+}
+
+// RemoteURL returns the first configured URL of the named remote
+func RemoteURL(r *Repository, name string) (string, error) {
+	repo := r.repo
+
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote %s: %w", name, err)
+	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return urls[0], nil
+	}
+
+	return "", fmt.Errorf("remote %s has no URL", name)
+}
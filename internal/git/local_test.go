@@ -0,0 +1,129 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+func TestOpenLocalRepository(t *testing.T) {
+	dir, _ := testutil.NewFixtureRepo(t, map[string]string{"src/main.go": "package main\n"})
+
+	// Opening from a subdirectory should find the repository root
+	root, err := OpenLocalRepository(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatalf("OpenLocalRepository() error = %v", err)
+	}
+	if root != dir {
+		t.Errorf("OpenLocalRepository() = %s, want %s", root, dir)
+	}
+
+	if _, err := OpenLocalRepository(t.TempDir()); err == nil {
+		t.Errorf("OpenLocalRepository() should fail outside a repository")
+	}
+}
+
+func TestGetChangedFilesLocal(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n", "b.txt": "bee\n"},
+		map[string]string{"a.txt": "one\ntwo\n", "b.txt": "", "c.txt": "sea\n"},
+	)
+
+	changes, err := GetChangedFiles(context.Background(), openTestRepo(t, dir), hashes[0], hashes[1], DefaultDiffOptions)
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for _, change := range changes {
+		got[change.Path] = change.ChangeType
+	}
+	want := map[string]string{"a.txt": "modified", "b.txt": "deleted", "c.txt": "added"}
+	for path, changeType := range want {
+		if got[path] != changeType {
+			t.Errorf("change for %s = %q, want %q", path, got[path], changeType)
+		}
+	}
+}
+
+func TestGetChangedFilesLineStats(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\ntwo\nthree\n", "b.txt": "bee\n"},
+		map[string]string{"a.txt": "one\n2\nthree\nfour", "b.txt": ""},
+	)
+
+	changes, err := GetChangedFiles(context.Background(), openTestRepo(t, dir), hashes[0], hashes[1], DefaultDiffOptions)
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+
+	want := map[string][2]int{"a.txt": {2, 1}, "b.txt": {0, 1}}
+	for _, change := range changes {
+		if got := [2]int{change.Additions, change.Deletions}; got != want[change.Path] {
+			t.Errorf("line stats for %s = %v, want %v", change.Path, got, want[change.Path])
+		}
+	}
+}
+
+func TestGetChangedFilesRenamesAndCopies(t *testing.T) {
+	// Distinct contents, so each added file has exactly one plausible source
+	body := strings.Repeat("a line of content that is long enough to matter\n", 20)
+	lib := strings.Repeat("func helper() int { return 42 } // library code\n", 20)
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"old/name.go": body, "lib.go": lib},
+		map[string]string{
+			"old/name.go": "",
+			"new/name.go": body + "one more line\n",
+			"lib.go":      lib + "tail changed\n",
+			"lib_copy.go": lib,
+		},
+	)
+	repo := openTestRepo(t, dir)
+
+	opts := DefaultDiffOptions
+	opts.DetectCopies = true
+	changes, err := GetChangedFiles(context.Background(), repo, hashes[0], hashes[1], opts)
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+
+	byPath := map[string]FileChange{}
+	for _, change := range changes {
+		byPath[change.Path] = change
+	}
+
+	rename := byPath["new/name.go"]
+	if rename.ChangeType != "renamed" || rename.OldPath != "old/name.go" || rename.Similarity < 90 || rename.Similarity == 100 {
+		t.Errorf("rename = %+v, want renamed from old/name.go with high similarity", rename)
+	}
+
+	copied := byPath["lib_copy.go"]
+	if copied.ChangeType != "copied" || copied.OldPath != "lib.go" || copied.Similarity != 100 {
+		t.Errorf("copy = %+v, want exact copy of lib.go", copied)
+	}
+
+	// Without rename detection the same range is an add plus a delete
+	changes, err = GetChangedFiles(context.Background(), repo, hashes[0], hashes[1], DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	for _, change := range changes {
+		if change.ChangeType == "renamed" || change.ChangeType == "copied" {
+			t.Errorf("unexpected %s change without detection: %+v", change.ChangeType, change)
+		}
+	}
+}
+
+// openTestRepo opens the fixture repository at dir
+func openTestRepo(t *testing.T, dir string) *Repository {
+	t.Helper()
+
+	repo, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture repository: %v", err)
+	}
+	return repo
+}
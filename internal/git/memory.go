@@ -0,0 +1,49 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// CloneMemory clones a Git repository into memory, with no worktree and
+// nothing written to disk. Start and End narrow the fetch as for
+// CloneRepository; TempDir is unused. Cancelling ctx aborts the clone. The
+// clone is freed once the returned Repository is no longer referenced.
+func CloneMemory(ctx context.Context, opts CloneOptions) (*Repository, error) {
+	auth, err := getAuth(opts.URL, opts.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up authentication: %w", &AuthError{URL: opts.URL, Err: err})
+	}
+
+	// Fetch just the range when the refs allow it, else start over with a full clone
+	var repo *git.Repository
+	if opts.Start != "" && opts.End != "" {
+		if repo, err = git.Init(memory.NewStorage(), nil); err != nil {
+			return nil, fmt.Errorf("failed to initialize repository: %w", err)
+		}
+		if err := cloneRange(ctx, repo, opts, auth); err != nil {
+			if !errors.Is(err, errNeedFullClone) {
+				return nil, err
+			}
+			repo = nil
+		}
+	}
+
+	if repo == nil {
+		repo, err = git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+			URL:               opts.URL,
+			Auth:              auth,
+			Progress:          opts.Progress,
+			RecurseSubmodules: git.NoRecurseSubmodules,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone repository: %w", remoteError(opts.URL, err))
+		}
+	}
+
+	return &Repository{repo: repo}, nil
+}
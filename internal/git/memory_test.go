@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+func TestCloneMemory(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n", "b.txt": "new\n"},
+	)
+	ctx := context.Background()
+
+	repo, err := CloneMemory(ctx, CloneOptions{URL: "file://" + dir})
+	if err != nil {
+		t.Fatalf("CloneMemory() error = %v", err)
+	}
+	if repo.Path() != "" {
+		t.Errorf("CloneMemory() path = %q, want none", repo.Path())
+	}
+
+	branches, err := ListBranches(ctx, repo)
+	if err != nil {
+		t.Fatalf("ListBranches() error = %v", err)
+	}
+	if len(branches) == 0 {
+		t.Errorf("ListBranches() found no branches")
+	}
+
+	changes, err := GetChangedFiles(ctx, repo, hashes[0], hashes[1], DefaultDiffOptions)
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("GetChangedFiles() returned %d changes, want 2", len(changes))
+	}
+}
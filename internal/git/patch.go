@@ -0,0 +1,216 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// WritePatch writes a unified diff between two references to w in the format
+// produced by "git diff --binary", so it can be applied with "git apply".
+// Binary files are encoded as GIT binary patches rather than skipped, and
+// renames are detected according to opts (copies are written as additions).
+// When include is not nil, only changes with an old or new path for which it
+// returns true are diffed and written; the missing side of an addition or
+// deletion is never passed to it, and a rename is only paired when it accepts
+// both paths. Cancelling ctx stops between files.
+func WritePatch(ctx context.Context, w io.Writer, r *Repository, startRef, endRef string, opts DiffOptions, include func(path string) bool) error {
+	repo := r.repo
+
+	changes, _, err := diffRefs(ctx, repo, startRef, endRef, opts, include)
+	if err != nil {
+		return err
+	}
+
+	encoder := fdiff.NewUnifiedEncoder(w, fdiff.DefaultContextLines)
+	for _, change := range changes {
+		// Submodule entries point at commits, not blobs, and have no content diff
+		if change.From.TreeEntry.Mode == filemode.Submodule || change.To.TreeEntry.Mode == filemode.Submodule {
+			continue
+		}
+
+		filePatch, err := change.PatchContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", changePath(change), ctxErr(ctx, err))
+		}
+
+		for _, fp := range filePatch.FilePatches() {
+			if fp.IsBinary() {
+				err = writeBinaryPatch(w, repo, fp)
+			} else {
+				err = encoder.Encode(singleFilePatch{fp})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write patch for %s: %w", changePath(change), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// singleFilePatch adapts one file patch to the diff.Patch interface so it can
+// be passed to the unified encoder on its own
+type singleFilePatch struct {
+	filePatch fdiff.FilePatch
+}
+
+func (p singleFilePatch) FilePatches() []fdiff.FilePatch { return []fdiff.FilePatch{p.filePatch} }
+func (p singleFilePatch) Message() string                { return "" }
+
+// includesChange reports whether include accepts the old or new path of change
+func includesChange(include func(path string) bool, change *object.Change) bool {
+	return (change.From.Name != "" && include(change.From.Name)) ||
+		(change.To.Name != "" && include(change.To.Name))
+}
+
+// changePath returns the most relevant path of a tree change for messages
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// writeBinaryPatch writes a file patch as a GIT binary patch with a forward
+// and a reverse literal hunk. git apply requires full hashes on the index line.
+func writeBinaryPatch(w io.Writer, repo *git.Repository, fp fdiff.FilePatch) error {
+	from, to := fp.Files()
+
+	var b strings.Builder
+	switch {
+	case from == nil:
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", to.Path(), to.Path())
+		fmt.Fprintf(&b, "new file mode %o\n", to.Mode())
+		fmt.Fprintf(&b, "index %s..%s\n", plumbing.ZeroHash, to.Hash())
+	case to == nil:
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", from.Path(), from.Path())
+		fmt.Fprintf(&b, "deleted file mode %o\n", from.Mode())
+		fmt.Fprintf(&b, "index %s..%s\n", from.Hash(), plumbing.ZeroHash)
+	default:
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", from.Path(), to.Path())
+		if from.Mode() != to.Mode() {
+			fmt.Fprintf(&b, "old mode %o\n", from.Mode())
+			fmt.Fprintf(&b, "new mode %o\n", to.Mode())
+		}
+		if from.Path() != to.Path() {
+			fmt.Fprintf(&b, "rename from %s\n", from.Path())
+			fmt.Fprintf(&b, "rename to %s\n", to.Path())
+		}
+		if from.Hash() == to.Hash() {
+			_, err := io.WriteString(w, b.String())
+			return err
+		}
+		if from.Mode() != to.Mode() {
+			fmt.Fprintf(&b, "index %s..%s\n", from.Hash(), to.Hash())
+		} else {
+			fmt.Fprintf(&b, "index %s..%s %o\n", from.Hash(), to.Hash(), from.Mode())
+		}
+	}
+
+	oldData, err := fileData(repo, from)
+	if err != nil {
+		return err
+	}
+	newData, err := fileData(repo, to)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("GIT binary patch\n")
+	if err := writeLiteralHunk(&b, newData); err != nil {
+		return err
+	}
+	if err := writeLiteralHunk(&b, oldData); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// fileData reads the full contents of one side of a file patch
+func fileData(repo *git.Repository, file fdiff.File) ([]byte, error) {
+	if file == nil {
+		return nil, nil
+	}
+
+	blob, err := repo.BlobObject(file.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", file.Hash(), err)
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// writeLiteralHunk writes data as a zlib-deflated, base85-encoded literal
+// hunk, 52 bytes per line, as understood by git apply
+func writeLiteralHunk(b *strings.Builder, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "literal %d\n", len(data))
+	deflated := compressed.Bytes()
+	for len(deflated) > 0 {
+		n := len(deflated)
+		if n > 52 {
+			n = 52
+		}
+
+		// The line length is encoded as A-Z for 1-26 and a-z for 27-52
+		if n <= 26 {
+			b.WriteByte(byte('A' + n - 1))
+		} else {
+			b.WriteByte(byte('a' + n - 27))
+		}
+		b.WriteString(encodeBase85(deflated[:n]))
+		b.WriteByte('\n')
+
+		deflated = deflated[n:]
+	}
+	b.WriteByte('\n')
+
+	return nil
+}
+
+// base85Alphabet is the character set git uses for binary patches
+const base85Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+// encodeBase85 encodes data in git's base85 flavour, zero-padding the last group
+func encodeBase85(data []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(data); i += 4 {
+		var group [4]byte
+		copy(group[:], data[i:])
+		value := uint32(group[0])<<24 | uint32(group[1])<<16 | uint32(group[2])<<8 | uint32(group[3])
+
+		var encoded [5]byte
+		for j := 4; j >= 0; j-- {
+			encoded[j] = base85Alphabet[value%85]
+			value /= 85
+		}
+		b.Write(encoded[:])
+	}
+	return b.String()
+}
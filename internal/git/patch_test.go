@@ -0,0 +1,176 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestEncodeBase85(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{0, 0, 0, 0}, "00000"},
+		{[]byte{0, 0, 0, 1}, "00001"},
+		{[]byte{0, 0, 0, 85}, "00010"},
+		{[]byte{0xff, 0xff, 0xff, 0xff}, "|NsC0"},
+		{[]byte{0, 0, 0, 0, 0}, "0000000000"}, // The last group is zero-padded
+	}
+	for _, tt := range tests {
+		if got := encodeBase85(tt.data); got != tt.want {
+			t.Errorf("encodeBase85(%v) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+// TestWritePatchApplies checks the patch against git itself: applied to the
+// start commit, it must reproduce the end commit's tree exactly
+func TestWritePatchApplies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	binary := bytes.Repeat([]byte{0, 1, 2, 3, 0xfe, 0xff, '\n'}, 40)
+	renamed := strings.Repeat("a line that moves with its file\n", 10)
+	dir, hashes := testutil.NewFixtureRepo(t, map[string]string{
+		"text.txt":  "one\ntwo\nthree\n",
+		"image.bin": string(binary),
+		"old.txt":   renamed,
+		"run.sh":    "echo hi\n",
+		"gone.bin":  string(binary[:50]),
+	})
+
+	// Second commit: edit text and binary, rename, add a binary, delete a
+	// binary and make run.sh executable
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	writes := map[string][]byte{
+		"text.txt":  []byte("one\n2\nthree\nfour\n"),
+		"image.bin": append([]byte{0xca, 0xfe}, binary...),
+		"new.txt":   []byte(renamed),
+		"added.bin": binary[:100],
+	}
+	for path, data := range writes {
+		if err := os.WriteFile(filepath.Join(dir, path), data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	for _, path := range []string{"old.txt", "gone.bin"} {
+		if err := os.Remove(filepath.Join(dir, path)); err != nil {
+			t.Fatalf("Failed to remove %s: %v", path, err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0755); err != nil {
+		t.Fatalf("Failed to chmod run.sh: %v", err)
+	}
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatalf("Failed to stage changes: %v", err)
+	}
+	signature := &object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)}
+	end, err := worktree.Commit("commit B", &git.CommitOptions{Author: signature, Committer: signature})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	endCommit, err := repo.CommitObject(end)
+	if err != nil {
+		t.Fatalf("Failed to load end commit: %v", err)
+	}
+
+	var patch bytes.Buffer
+	if err := WritePatch(context.Background(), &patch, openTestRepo(t, dir), hashes[0], end.String(), DefaultDiffOptions, nil); err != nil {
+		t.Fatalf("WritePatch() error = %v", err)
+	}
+	for _, want := range []string{
+		"rename from old.txt\nrename to new.txt\n",
+		"old mode 100644\nnew mode 100755\n",
+		"diff --git a/image.bin b/image.bin\nindex ",
+		"new file mode 100644\nindex 0000000000000000000000000000000000000000..",
+		"deleted file mode 100644\n",
+		"GIT binary patch\nliteral 282\n",
+		"-two\n+2\n",
+	} {
+		if !strings.Contains(patch.String(), want) {
+			t.Errorf("patch does not contain %q", want)
+		}
+	}
+
+	checkout := filepath.Join(t.TempDir(), "checkout")
+	patchFile := filepath.Join(t.TempDir(), "changes.patch")
+	if err := os.WriteFile(patchFile, patch.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write patch: %v", err)
+	}
+	for _, args := range [][]string{
+		{"clone", "-q", dir, checkout},
+		{"-C", checkout, "checkout", "-q", hashes[0]},
+		{"-C", checkout, "apply", "--index", patchFile},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	out, err := exec.Command("git", "-C", checkout, "write-tree").Output()
+	if err != nil {
+		t.Fatalf("git write-tree: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != endCommit.TreeHash.String() {
+		t.Errorf("applied tree = %s, want end tree %s", got, endCommit.TreeHash)
+	}
+}
+
+func TestWritePatchIncludeAddition(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n", "vendor/lib.txt": "new\n"},
+	)
+
+	// Like an exclude-only filter, this accepts the empty name of the missing side
+	include := func(path string) bool { return !strings.HasPrefix(path, "vendor/") }
+	var patch bytes.Buffer
+	if err := WritePatch(context.Background(), &patch, openTestRepo(t, dir), hashes[0], hashes[1], DefaultDiffOptions, include); err != nil {
+		t.Fatalf("WritePatch() error = %v", err)
+	}
+	if strings.Contains(patch.String(), "vendor/lib.txt") {
+		t.Errorf("patch contains the excluded added file:\n%s", patch.String())
+	}
+	if !strings.Contains(patch.String(), "a/a.txt") {
+		t.Errorf("patch is missing a.txt:\n%s", patch.String())
+	}
+}
+
+func TestWritePatchIncludeRename(t *testing.T) {
+	content := "line one\nline two\nline three\nline four\n"
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"old.txt": content, "a.txt": "one\n"},
+		map[string]string{"old.txt": "", "new.txt": content, "a.txt": "two\n"},
+	)
+
+	// Only the renamed file's two paths are diffed, and still paired
+	include := func(path string) bool { return path == "old.txt" || path == "new.txt" }
+	var patch bytes.Buffer
+	if err := WritePatch(context.Background(), &patch, openTestRepo(t, dir), hashes[0], hashes[1], DefaultDiffOptions, include); err != nil {
+		t.Fatalf("WritePatch() error = %v", err)
+	}
+	if !strings.Contains(patch.String(), "rename from old.txt\nrename to new.txt\n") {
+		t.Errorf("patch does not pair the rename:\n%s", patch.String())
+	}
+	if strings.Contains(patch.String(), "a.txt") {
+		t.Errorf("patch contains a file outside include:\n%s", patch.String())
+	}
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// Repository is an open repository for the functions of this package to
+// read: a repository on disk or a clone held in memory
+type Repository struct {
+	repo *git.Repository
+	path string // Directory on disk, "" in memory
+
+	tagsOnce sync.Once
+	tagNames map[string][]string // Tag names by commit hash, see commitTags
+}
+
+// OpenRepository opens the repository at path, which must be its root (or
+// the bare repository itself)
+func OpenRepository(path string) (*Repository, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Repository{repo: repo, path: path}, nil
+}
+
+// Path returns the directory of the repository, or "" for an in-memory clone
+func (r *Repository) Path() string {
+	return r.path
+}
@@ -2,28 +2,84 @@ package git
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
-// ResolveRef tries to resolve a reference using multiple formats
+// ResolveRef resolves a revision expression to a commit hash. Besides branch,
+// tag and remote branch names it accepts (abbreviated) hashes, HEAD~3,
+// main^2, main@{2 weeks ago}, :/message and ^{/message}; see parseRevision.
+// Unknown names produce an error suggesting similar branches and tags.
 func ResolveRef(repo *git.Repository, ref string) (*plumbing.Hash, error) {
-	// Try different ref formats
-	refFormats := []string{
-		ref,                                    // As-is
-		"refs/heads/" + ref,                    // Local branch
-		"refs/remotes/origin/" + ref,           // Remote branch
-		"origin/" + ref,                        // Remote branch shorthand
-		"refs/tags/" + ref,                    // Tag
+	rev, err := parseRevision(ref)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, refFormat := range refFormats {
-		hash, err := repo.ResolveRevision(plumbing.Revision(refFormat))
-		if err == nil {
-			return hash, nil
-		}
+	commit, err := resolveRevision(repo, rev, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("reference not found: %s", ref)
+	return &commit.Hash, nil
+}
+
+// SplitRange splits a three-dot range such as "main...feature" into its start
+// and end references. As in git, an empty side means HEAD. ok is false when
+// ref is not a three-dot range.
+func SplitRange(ref string) (start, end string, ok bool) {
+	// ":/" searches take any text, dots included
+	if strings.HasPrefix(ref, ":/") {
+		return ref, "", false
+	}
+	start, end, ok = strings.Cut(ref, "...")
+	if !ok {
+		return ref, "", false
+	}
+	if start == "" {
+		start = "HEAD"
+	}
+	if end == "" {
+		end = "HEAD"
+	}
+	return start, end, true
+}
+
+// MergeBase returns the hash of the best common ancestor of two references,
+// the commit GitHub's compare view diffs from
+func MergeBase(r *Repository, startRef, endRef string) (string, error) {
+	repo := r.repo
+
+	startHash, err := ResolveRef(repo, startRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve start reference %s: %w", startRef, err)
+	}
+	endHash, err := ResolveRef(repo, endRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve end reference %s: %w", endRef, err)
+	}
+
+	startCommit, err := repo.CommitObject(*startHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get start commit: %w", err)
+	}
+	endCommit, err := repo.CommitObject(*endHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get end commit: %w", err)
+	}
+
+	bases, err := startCommit.MergeBase(endCommit)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %w", err)
+	}
+	if len(bases) == 0 {
+		return "", &NotFoundError{Kind: "commit", Name: fmt.Sprintf("common ancestor of %s and %s", startRef, endRef)}
+	}
+
+	// Criss-cross merges can leave several equally good bases; like
+	// "git merge-base" without --all, pick one of them
+	return bases[0].Hash.String(), nil
 }
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+func TestSplitRange(t *testing.T) {
+	tests := []struct {
+		ref, start, end string
+		ok              bool
+	}{
+		{"main...feature", "main", "feature", true},
+		{"v1.0.0...", "v1.0.0", "HEAD", true},
+		{"...feature", "HEAD", "feature", true},
+		{"main", "main", "", false},
+		{"main..feature", "main..feature", "", false},
+	}
+
+	for _, tt := range tests {
+		start, end, ok := SplitRange(tt.ref)
+		if start != tt.start || end != tt.end || ok != tt.ok {
+			t.Errorf("SplitRange(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.ref, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+
+	base, err := MergeBase(openTestRepo(t, dir), hashes[2], hashes[0])
+	if err != nil {
+		t.Fatalf("MergeBase() error = %v", err)
+	}
+	if base != hashes[0] {
+		t.Errorf("MergeBase() = %s, want ancestor %s", base, hashes[0])
+	}
+}
+
+func TestMergeBaseDiverged(t *testing.T) {
+	// main:    A - B        (B edits a.txt)
+	// feature:  \- C - D    (C edits b.txt, D adds c.txt)
+	dir, main := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n", "b.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+	)
+	feature := testutil.ForkFixtureRepo(t, dir, "feature", main[0],
+		map[string]string{"b.txt": "two\n"},
+		map[string]string{"c.txt": "new\n"},
+	)
+	repo := openTestRepo(t, dir)
+
+	base, err := MergeBase(repo, "master", "feature")
+	if err != nil {
+		t.Fatalf("MergeBase() error = %v", err)
+	}
+	if base != main[0] {
+		t.Errorf("MergeBase() = %s, want fork point %s", base, main[0])
+	}
+
+	// From the merge base only the feature's own work shows up; a plain diff
+	// also reports main's edit to a.txt as reverted
+	tests := []struct {
+		start string
+		want  string
+	}{
+		{base, "b.txt,c.txt"},
+		{"master", "a.txt,b.txt,c.txt"},
+	}
+	for _, tt := range tests {
+		changes, err := GetChangedFiles(context.Background(), repo, tt.start, feature[1], DefaultDiffOptions)
+		if err != nil {
+			t.Fatalf("GetChangedFiles() error = %v", err)
+		}
+		var paths []string
+		for _, change := range changes {
+			paths = append(paths, change.Path)
+		}
+		sort.Strings(paths)
+		if got := strings.Join(paths, ","); got != tt.want {
+			t.Errorf("GetChangedFiles(%s) = %s, want %s", tt.start, got, tt.want)
+		}
+	}
+}
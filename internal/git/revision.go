@@ -0,0 +1,556 @@
+package git
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// minHashPrefix is the shortest abbreviated hash accepted, as in git
+const minHashPrefix = 4
+
+// revisionStep is one suffix operator applied to a commit, such as "~2",
+// "^2" or "^{/fix}"
+type revisionStep struct {
+	op      byte // '~' or '^'
+	n       int
+	pattern *regexp.Regexp // For ^{/regex}
+	negate  bool           // For ^{/!-regex}
+}
+
+// revision is a parsed revision expression: a base name, an optional date
+// from "@{...}" and the suffix steps that follow
+type revision struct {
+	base   string
+	date   string
+	search *regexp.Regexp // For ":/regex"
+	steps  []revisionStep
+}
+
+// parseRevision parses the subset of gitrevisions(7) supported by
+// ResolveRef: <ref>, <sha>, @, <ref>@{<date>}, :/<regex>, and any number of
+// ~<n>, ^<n>, ^{}, ^{commit} and ^{/<regex>} suffixes
+func parseRevision(expr string) (*revision, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("empty revision")
+	}
+
+	// ":/text" searches commit messages reachable from any ref
+	if strings.HasPrefix(expr, ":/") {
+		pattern, err := regexp.Compile(expr[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid message pattern in %s: %w", expr, err)
+		}
+		return &revision{search: pattern}, nil
+	}
+
+	rev := &revision{}
+	rest := expr
+	end := strings.IndexAny(rest, "~^")
+	if at := strings.Index(rest, "@{"); at >= 0 && (end < 0 || at < end) {
+		end = at
+	}
+	if end < 0 {
+		end = len(rest)
+	}
+	rev.base, rest = rest[:end], rest[end:]
+
+	// "@{...}" may only follow the name
+	if strings.HasPrefix(rest, "@{") {
+		closing := strings.Index(rest, "}")
+		if closing < 0 {
+			return nil, fmt.Errorf("unterminated @{ in %s", expr)
+		}
+		rev.date, rest = rest[2:closing], rest[closing+1:]
+		if rev.date == "" {
+			return nil, fmt.Errorf("empty @{} in %s", expr)
+		}
+	}
+	if rev.base == "" || rev.base == "@" {
+		rev.base = "HEAD"
+	}
+
+	for rest != "" {
+		op := rest[0]
+		rest = rest[1:]
+		if op != '~' && op != '^' {
+			return nil, fmt.Errorf("unexpected %q in %s", op, expr)
+		}
+
+		// ^{...} peels or searches
+		if op == '^' && strings.HasPrefix(rest, "{") {
+			closing := strings.Index(rest, "}")
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated ^{ in %s", expr)
+			}
+			inner := rest[1:closing]
+			rest = rest[closing+1:]
+
+			switch {
+			case inner == "" || inner == "commit":
+				// Revisions always resolve to commits already
+			case strings.HasPrefix(inner, "/"):
+				step := revisionStep{op: '^'}
+				text := inner[1:]
+				if strings.HasPrefix(text, "!-") {
+					step.negate, text = true, text[2:]
+				}
+				pattern, err := regexp.Compile(text)
+				if err != nil {
+					return nil, fmt.Errorf("invalid message pattern in %s: %w", expr, err)
+				}
+				step.pattern = pattern
+				rev.steps = append(rev.steps, step)
+			default:
+				return nil, fmt.Errorf("unsupported ^{%s} in %s", inner, expr)
+			}
+			continue
+		}
+
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(rest[:digits])
+			rest = rest[digits:]
+		}
+		rev.steps = append(rev.steps, revisionStep{op: op, n: n})
+	}
+
+	return rev, nil
+}
+
+// resolveRevision evaluates a parsed revision to a commit
+func resolveRevision(repo *git.Repository, rev *revision, now time.Time) (*object.Commit, error) {
+	var commit *object.Commit
+	var err error
+
+	if rev.search != nil {
+		tips, err := refTips(repo)
+		if err != nil {
+			return nil, err
+		}
+		commit, err = youngestMatch(tips, rev.search, false)
+		if err != nil {
+			return nil, err
+		}
+		if commit == nil {
+			return nil, &NotFoundError{Kind: "commit", Name: fmt.Sprintf("message matching %q", rev.search)}
+		}
+		return commit, nil
+	}
+
+	commit, err = resolveName(repo, rev.base)
+	if err != nil {
+		return nil, err
+	}
+
+	if rev.date != "" {
+		if commit, err = commitAtDate(commit, rev.base, rev.date, now); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, step := range rev.steps {
+		switch {
+		case step.pattern != nil:
+			match, err := youngestMatch([]*object.Commit{commit}, step.pattern, step.negate)
+			if err != nil {
+				return nil, err
+			}
+			if match == nil {
+				return nil, &NotFoundError{Kind: "commit", Name: fmt.Sprintf("reachable from %s with message matching %q", commit.Hash.String()[:7], step.pattern)}
+			}
+			commit = match
+		case step.op == '~':
+			for i := 0; i < step.n; i++ {
+				if commit.NumParents() == 0 {
+					return nil, &NotFoundError{Kind: "commit", Name: "parent of " + commit.Hash.String()[:7]}
+				}
+				if commit, err = commit.Parent(0); err != nil {
+					return nil, err
+				}
+			}
+		case step.n > 0:
+			if step.n > commit.NumParents() {
+				return nil, &NotFoundError{Kind: "commit", Name: fmt.Sprintf("parent %d of %s (it has %d)", step.n, commit.Hash.String()[:7], commit.NumParents())}
+			}
+			if commit, err = commit.Parent(step.n - 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return commit, nil
+}
+
+// resolveName resolves a ref name, HEAD, or a full or abbreviated hash to a
+// commit, peeling annotated tags
+func resolveName(repo *git.Repository, name string) (*object.Commit, error) {
+	// Full hashes need no lookup
+	if len(name) == 40 && isHex(name) {
+		commit, err := peelToCommit(repo, plumbing.NewHash(name))
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, &NotFoundError{Kind: "commit", Name: name}
+		}
+		return commit, err
+	}
+
+	// Full ref name, local branch, origin's branch, other remotes, tag
+	candidates := []string{
+		name,
+		"refs/heads/" + name,
+		"refs/remotes/origin/" + name,
+		"refs/remotes/" + name,
+		"refs/tags/" + name,
+	}
+	for _, candidate := range candidates {
+		ref, err := repo.Reference(plumbing.ReferenceName(candidate), true)
+		if err == nil {
+			return peelToCommit(repo, ref.Hash())
+		}
+	}
+
+	if len(name) >= minHashPrefix && isHex(name) {
+		commits, err := commitsWithPrefix(repo, strings.ToLower(name))
+		if err != nil {
+			return nil, err
+		}
+		switch len(commits) {
+		case 1:
+			return commits[0], nil
+		case 0:
+		default:
+			lines := make([]string, len(commits))
+			for i, c := range commits {
+				lines[i] = fmt.Sprintf("%s %s %s", c.Hash.String()[:12], c.Committer.When.Format("2006-01-02"), firstLine(c.Message))
+			}
+			return nil, &AmbiguousRefError{Ref: name, Candidates: lines}
+		}
+	}
+
+	return nil, &NotFoundError{Kind: "reference", Name: name, Suggestions: suggestRefs(repo, name)}
+}
+
+// peelToCommit returns the commit at hash, following annotated tags
+func peelToCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// commitsWithPrefix returns the distinct commits whose hash, or whose
+// annotated tag's hash, starts with the hex prefix
+func commitsWithPrefix(repo *git.Repository, prefix string) ([]*object.Commit, error) {
+	prefixBytes, err := hex.DecodeString(prefix[:len(prefix)&^1])
+	if err != nil {
+		return nil, err
+	}
+
+	// The filesystem storage can search its indexes directly
+	var hashes []plumbing.Hash
+	if fast, ok := repo.Storer.(interface {
+		HashesWithPrefix(prefix []byte) ([]plumbing.Hash, error)
+	}); ok {
+		if hashes, err = fast.HashesWithPrefix(prefixBytes); err != nil {
+			return nil, err
+		}
+	} else {
+		iter, err := repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+		if err != nil {
+			return nil, err
+		}
+		err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+			if hash := obj.Hash(); bytes.HasPrefix(hash[:], prefixBytes) {
+				hashes = append(hashes, hash)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	commits := []*object.Commit{}
+	for _, hash := range hashes {
+		if !strings.HasPrefix(hash.String(), prefix) {
+			continue // The odd trailing digit did not match
+		}
+		// Trees and blobs can share a prefix but are not revisions
+		commit, err := peelToCommit(repo, hash)
+		if err != nil || seen[commit.Hash] {
+			continue
+		}
+		seen[commit.Hash] = true
+		commits = append(commits, commit)
+	}
+
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Hash.String() < commits[j].Hash.String() })
+	return commits, nil
+}
+
+// commitAtDate approximates <ref>@{<date>}. Without a reflog it returns the
+// newest commit on the first-parent history of tip committed at or before
+// the date.
+func commitAtDate(tip *object.Commit, name, spec string, now time.Time) (*object.Commit, error) {
+	if _, err := strconv.Atoi(spec); err == nil {
+		return nil, fmt.Errorf("reflog entries like %s@{%s} are not available; use %s~%s or a date", name, spec, name, spec)
+	}
+	date, err := parseApproxDate(spec, now)
+	if err != nil {
+		return nil, err
+	}
+	return firstParentAt(tip, name, date)
+}
+
+// firstParentAt returns the newest commit on the first-parent history of tip
+// committed at or before date
+func firstParentAt(tip *object.Commit, name string, date time.Time) (*object.Commit, error) {
+	commit := tip
+	var err error
+	for commit.Committer.When.After(date) {
+		if commit.NumParents() == 0 {
+			return nil, &NotFoundError{Kind: "commit", Name: fmt.Sprintf("%s as of %s (its history starts later)", name, date.Format("2006-01-02 15:04"))}
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			return nil, err
+		}
+	}
+	return commit, nil
+}
+
+// dateUnits maps the units accepted in "<n> <unit> ago" to durations
+var dateUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// ParseDate parses a date given on the command line, relative to now. See
+// parseApproxDate for the accepted forms.
+func ParseDate(spec string) (time.Time, error) {
+	return parseApproxDate(spec, time.Now())
+}
+
+// parseApproxDate parses the date forms most used with git: "yesterday",
+// "<n> <unit>(s) ago" (also written "2.weeks.ago"), and absolute dates such
+// as "2024-01-31", "2024-01-31 12:00" or RFC 3339 timestamps
+func parseApproxDate(spec string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(spec, ".", " ")))
+	switch text {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if fields := strings.Fields(text); len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", spec)
+		}
+		unit := strings.TrimSuffix(fields[1], "s")
+		switch unit {
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+		if d, ok := dateUnits[unit]; ok {
+			return now.Add(-time.Duration(n) * d), nil
+		}
+		return time.Time{}, fmt.Errorf("invalid date unit %q in %q", fields[1], spec)
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if date, err := time.ParseInLocation(layout, strings.TrimSpace(spec), now.Location()); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected e.g. \"2 weeks ago\", \"yesterday\" or \"2024-01-31\")", spec)
+}
+
+// refTips returns the commits at the tips of all branches and tags, and HEAD
+func refTips(repo *git.Repository) ([]*object.Commit, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate references: %w", err)
+	}
+	defer refs.Close()
+
+	tips := []*object.Commit{}
+	seen := make(map[plumbing.Hash]bool)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || seen[ref.Hash()] {
+			return nil
+		}
+		seen[ref.Hash()] = true
+		if commit, err := peelToCommit(repo, ref.Hash()); err == nil {
+			tips = append(tips, commit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process references: %w", err)
+	}
+	if head, err := repo.Head(); err == nil && !seen[head.Hash()] {
+		if commit, err := peelToCommit(repo, head.Hash()); err == nil {
+			tips = append(tips, commit)
+		}
+	}
+	return tips, nil
+}
+
+// commitHeap orders commits newest first by committer date
+type commitHeap []*object.Commit
+
+func (h commitHeap) Len() int            { return len(h) }
+func (h commitHeap) Less(i, j int) bool  { return h[i].Committer.When.After(h[j].Committer.When) }
+func (h commitHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *commitHeap) Push(x interface{}) { *h = append(*h, x.(*object.Commit)) }
+func (h *commitHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// youngestMatch walks the history of all tips together, newest first, and
+// returns the first commit whose message matches pattern (or doesn't, when
+// negate is set). It returns nil if no commit qualifies.
+func youngestMatch(tips []*object.Commit, pattern *regexp.Regexp, negate bool) (*object.Commit, error) {
+	queue := &commitHeap{}
+	seen := make(map[plumbing.Hash]bool)
+	for _, tip := range tips {
+		if !seen[tip.Hash] {
+			seen[tip.Hash] = true
+			heap.Push(queue, tip)
+		}
+	}
+
+	for queue.Len() > 0 {
+		commit := heap.Pop(queue).(*object.Commit)
+		if pattern.MatchString(commit.Message) != negate {
+			return commit, nil
+		}
+
+		err := commit.Parents().ForEach(func(parent *object.Commit) error {
+			if !seen[parent.Hash] {
+				seen[parent.Hash] = true
+				heap.Push(queue, parent)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// suggestRefs returns up to three branch or tag names close to name
+func suggestRefs(repo *git.Repository, name string) []string {
+	refs, err := repo.References()
+	if err != nil {
+		return nil
+	}
+	defer refs.Close()
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	seen := make(map[string]bool)
+	matches := []scored{}
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		short := ref.Name().Short()
+		if ref.Name().IsRemote() {
+			// origin/feature -> feature
+			if _, branch, ok := strings.Cut(short, "/"); ok {
+				short = branch
+			}
+		}
+		if seen[short] || short == "HEAD" {
+			return nil
+		}
+		seen[short] = true
+
+		distance := editDistance(strings.ToLower(name), strings.ToLower(short))
+		if distance <= maxDistance || strings.Contains(strings.ToLower(short), strings.ToLower(name)) {
+			matches = append(matches, scored{short, distance})
+		}
+		return nil
+	})
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < 3; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// isHex reports whether s consists only of hexadecimal digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// firstLine returns the first line of a commit message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
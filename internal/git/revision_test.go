@@ -0,0 +1,144 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestResolveRefExpressions(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+	)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"HEAD", hashes[2]},
+		{"@~2", hashes[0]},
+		{"HEAD^^", hashes[0]},
+		{"HEAD^{/commit B}", hashes[1]},
+		{":/commit A", hashes[0]},
+		{hashes[1][:7] + "~1", hashes[0]},
+	}
+	for _, tt := range tests {
+		got, err := ResolveRef(repo, tt.ref)
+		if err != nil {
+			t.Errorf("ResolveRef(%q) error = %v", tt.ref, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ResolveRef(%q) = %s, want %s", tt.ref, got, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"HEAD~5", "HEAD^2", "HEAD@{1}", "HEAD^{tree}"} {
+		if _, err := ResolveRef(repo, ref); err == nil {
+			t.Errorf("ResolveRef(%q) should fail", ref)
+		}
+	}
+}
+
+func TestResolveRefSuggestions(t *testing.T) {
+	dir, _ := testutil.NewFixtureRepo(t, map[string]string{"a.txt": "one\n"})
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("PlainOpen() error = %v", err)
+	}
+	head, _ := repo.Head()
+	for _, name := range []string{"develop", "release/1.0"} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head.Hash())); err != nil {
+			t.Fatalf("SetReference() error = %v", err)
+		}
+	}
+
+	_, err = ResolveRef(repo, "devlop")
+	if err == nil || !strings.Contains(err.Error(), "did you mean develop") {
+		t.Errorf("ResolveRef(devlop) error = %v, want a suggestion of develop", err)
+	}
+}
+
+func TestResolveRefAmbiguousHash(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// Store commits until two share a four-digit prefix
+	byPrefix := make(map[string]string)
+	prefix := ""
+	when := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; prefix == "" && i < 5000; i++ {
+		commit := &object.Commit{
+			Author:    object.Signature{Name: "Tester", When: when},
+			Committer: object.Signature{Name: "Tester", When: when},
+			Message:   fmt.Sprintf("commit %d", i),
+			TreeHash:  plumbing.ZeroHash,
+		}
+		obj := repo.Storer.NewEncodedObject()
+		if err := commit.Encode(obj); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		hash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			t.Fatalf("SetEncodedObject() error = %v", err)
+		}
+		key := hash.String()[:minHashPrefix]
+		if _, ok := byPrefix[key]; ok {
+			prefix = key
+		}
+		byPrefix[key] = hash.String()
+	}
+	if prefix == "" {
+		t.Skip("no colliding prefix found")
+	}
+
+	_, err = ResolveRef(repo, prefix)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") || !strings.Contains(err.Error(), byPrefix[prefix][:12]) {
+		t.Errorf("ResolveRef(%s) error = %v, want an ambiguity error listing candidates", prefix, err)
+	}
+}
+
+func TestParseApproxDate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"yesterday", now.AddDate(0, 0, -1)},
+		{"2 weeks ago", now.AddDate(0, 0, -14)},
+		{"3.days.ago", now.AddDate(0, 0, -3)},
+		{"1 month ago", now.AddDate(0, -1, 0)},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-31 08:30", time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseApproxDate(tt.spec, now)
+		if err != nil {
+			t.Errorf("parseApproxDate(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseApproxDate(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	if _, err := parseApproxDate("fortnight", now); err == nil {
+		t.Errorf("parseApproxDate(fortnight) should fail")
+	}
+}
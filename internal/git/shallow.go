@@ -0,0 +1,221 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// shallowDepths are the history depths a range clone fetches in turn until
+// the range is complete, before fetching the full history of its refs
+var shallowDepths = []int{50, 500, 5000}
+
+// fullDepth asks the server for the complete history, like "git fetch --unshallow"
+const fullDepth = 1<<31 - 1
+
+// errNeedFullClone reports that a range clone cannot fetch what the range
+// needs, e.g. because a reference is an abbreviated hash, so every branch
+// must be cloned
+var errNeedFullClone = errors.New("range needs a full clone")
+
+// cloneRange fetches only the refs that opts.Start and opts.End are based on
+// into the empty bare repository repo, deepening the history until the commits
+// between them, and their merge base when opts.MergeBase is set, are
+// complete. It returns errNeedFullClone when the refs cannot be fetched on
+// their own.
+func cloneRange(ctx context.Context, repo *git.Repository, opts CloneOptions, auth transport.AuthMethod) error {
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{opts.URL},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure remote: %w", err)
+	}
+
+	remoteRefs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return fmt.Errorf("failed to list remote references: %w", remoteError(opts.URL, err))
+	}
+	refSpecs, head, err := rangeRefSpecs(remoteRefs, opts.Start, opts.End, "refs/remotes/origin/")
+	if err != nil {
+		return err
+	}
+	if head != "" {
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, head)); err != nil {
+			return fmt.Errorf("failed to set HEAD: %w", err)
+		}
+	}
+
+	return fetchRange(ctx, repo, remote, refSpecs, opts, auth)
+}
+
+// fetchRange fetches refSpecs from remote into repo, deepening the history
+// until the commits between opts.Start and opts.End, and their merge base
+// when opts.MergeBase is set, are complete
+func fetchRange(ctx context.Context, repo *git.Repository, remote *git.Remote, refSpecs []config.RefSpec, opts CloneOptions, auth transport.AuthMethod) error {
+	fetch := func(depth int) error {
+		err := remote.FetchContext(ctx, &git.FetchOptions{
+			RefSpecs: refSpecs,
+			Depth:    depth,
+			Auth:     auth,
+			Progress: opts.Progress,
+			Force:    true,
+		})
+		if errors.Is(err, git.ErrExactSHA1NotSupported) {
+			return errNeedFullClone
+		}
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return fmt.Errorf("failed to fetch: %w", remoteError(opts.URL, err))
+		}
+		return nil
+	}
+
+	for _, depth := range shallowDepths {
+		progressf(opts.Progress, "  Fetching the requested refs %d commits deep\n", depth)
+		if err := fetch(depth); err != nil {
+			return err
+		}
+		complete, err := rangeComplete(ctx, repo, opts.Start, opts.End, opts.MergeBase)
+		if err != nil {
+			return err
+		}
+		if complete {
+			return nil
+		}
+	}
+
+	progressf(opts.Progress, "  Fetching the full history of the requested refs\n")
+	if err := fetch(fullDepth); err != nil {
+		return err
+	}
+	// Nothing is missing any more, so nothing is shallow
+	if err := repo.Storer.SetShallow(nil); err != nil {
+		return fmt.Errorf("failed to update shallow list: %w", err)
+	}
+	return nil
+}
+
+// rangeRefSpecs returns the refspecs that fetch the refs start and end are
+// based on, and the local branch HEAD should point at when either is based on
+// HEAD. Branches are stored under branchPrefix: origin's remote-tracking
+// branches in a clone, the branches themselves in a mirror.
+func rangeRefSpecs(remoteRefs []*plumbing.Reference, start, end, branchPrefix string) ([]config.RefSpec, plumbing.ReferenceName, error) {
+	advertised := make(map[plumbing.ReferenceName]*plumbing.Reference)
+	for _, ref := range remoteRefs {
+		advertised[ref.Name()] = ref
+	}
+
+	var refSpecs []config.RefSpec
+	var head plumbing.ReferenceName
+	seen := make(map[string]bool)
+	for _, expr := range []string{start, end} {
+		rev, err := parseRevision(expr)
+		if err != nil {
+			return nil, "", err
+		}
+		// Message searches look through every ref
+		if rev.search != nil {
+			return nil, "", errNeedFullClone
+		}
+		if seen[rev.base] {
+			continue
+		}
+		seen[rev.base] = true
+
+		name := strings.TrimPrefix(rev.base, "origin/")
+		switch {
+		case name == "HEAD":
+			remoteHead := advertised[plumbing.HEAD]
+			if remoteHead == nil || remoteHead.Type() != plumbing.SymbolicReference {
+				return nil, "", errNeedFullClone
+			}
+			head = remoteHead.Target()
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+%s:%s", head, head)))
+		case len(name) == 40 && isHex(name):
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("%s:refs/fetched/%s", name, name)))
+		case advertised[plumbing.NewBranchReferenceName(name)] != nil:
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+refs/heads/%s:%s%s", name, branchPrefix, name)))
+		case advertised[plumbing.NewTagReferenceName(name)] != nil:
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+refs/tags/%s:refs/tags/%s", name, name)))
+		case advertised[plumbing.ReferenceName(name)] != nil:
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+%s:%s", name, name)))
+		default:
+			// Abbreviated hashes and unknown names are resolved against every ref
+			return nil, "", errNeedFullClone
+		}
+	}
+	return refSpecs, head, nil
+}
+
+// rangeComplete reports whether a shallow repository holds everything needed
+// to compare start and end: both resolve, every commit reachable from end
+// but not from start is present, and so is their merge base if needed
+func rangeComplete(ctx context.Context, repo *git.Repository, start, end string, mergeBase bool) (bool, error) {
+	startHash, err := ResolveRef(repo, start)
+	if err != nil {
+		return false, nil
+	}
+	endHash, err := ResolveRef(repo, end)
+	if err != nil {
+		return false, nil
+	}
+
+	if mergeBase {
+		startCommit, err := repo.CommitObject(*startHash)
+		if err != nil {
+			return false, nil
+		}
+		endCommit, err := repo.CommitObject(*endHash)
+		if err != nil {
+			return false, nil
+		}
+		if bases, err := startCommit.MergeBase(endCommit); err != nil || len(bases) == 0 {
+			return false, nil
+		}
+	}
+
+	excluded, err := ancestors(ctx, repo, *startHash)
+	if err != nil {
+		return false, err
+	}
+
+	// Walk back from end until the start side's history; a missing commit on
+	// the way means the range reaches past the shallow boundary
+	seen := make(map[plumbing.Hash]bool)
+	pending := []plumbing.Hash{*endHash}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if excluded[hash] || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		commit, err := repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		pending = append(pending, commit.ParentHashes...)
+	}
+	return true, nil
+}
+
+// progressf writes a progress line to w unless it is nil
+func progressf(w io.Writer, format string, a ...interface{}) {
+	if w != nil {
+		fmt.Fprintf(w, format, a...)
+	}
+}
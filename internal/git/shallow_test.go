@@ -0,0 +1,113 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+)
+
+func TestCloneRepositoryRange(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+		map[string]string{"a.txt": "four\n"},
+		map[string]string{"a.txt": "five\n"},
+	)
+
+	defer func(depths []int) { shallowDepths = depths }(shallowDepths)
+	shallowDepths = []int{1, 3}
+
+	tests := []struct {
+		name        string
+		start       string
+		wantShallow bool
+		wantCommits int
+	}{
+		{"deepened until start", "HEAD~2", true, 2},
+		{"abbreviated hash", hashes[0][:7], false, 4},
+	}
+	for _, tt := range tests {
+		path, err := CloneRepository(context.Background(), CloneOptions{
+			URL:     "file://" + dir,
+			TempDir: t.TempDir(),
+			Start:   tt.start,
+			End:     "HEAD",
+		})
+		if err != nil {
+			t.Fatalf("%s: CloneRepository() error = %v", tt.name, err)
+		}
+
+		repo, err := git.PlainOpen(path)
+		if err != nil {
+			t.Fatalf("%s: failed to open clone: %v", tt.name, err)
+		}
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			t.Fatalf("%s: failed to read shallow list: %v", tt.name, err)
+		}
+		if got := len(shallow) > 0; got != tt.wantShallow {
+			t.Errorf("%s: shallow = %v, want %v", tt.name, got, tt.wantShallow)
+		}
+
+		commits, err := ListCommitRange(context.Background(), openTestRepo(t, path), tt.start, "HEAD")
+		if err != nil {
+			t.Errorf("%s: ListCommitRange() error = %v", tt.name, err)
+			continue
+		}
+		if len(commits) != tt.wantCommits || commits[0].Hash != hashes[4] {
+			t.Errorf("%s: ListCommitRange() returned %d commits, want %d ending at %s", tt.name, len(commits), tt.wantCommits, hashes[4])
+		}
+	}
+}
+
+func TestSyncMirrorRange(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+		map[string]string{"a.txt": "three\n"},
+		map[string]string{"a.txt": "four\n"},
+		map[string]string{"a.txt": "five\n"},
+	)
+	testutil.ForkFixtureRepo(t, dir, "feature", hashes[1], map[string]string{"b.txt": "feature\n"})
+
+	defer func(depths []int) { shallowDepths = depths }(shallowDepths)
+	shallowDepths = []int{1, 3}
+
+	// A new mirror fetches only the range's branch, as deep as it needs
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mirror")
+	opts := MirrorOptions{URL: "file://" + dir, Path: path, Start: "HEAD~2", End: "HEAD"}
+	if _, err := SyncMirror(ctx, opts); err != nil {
+		t.Fatalf("SyncMirror() with a range error = %v", err)
+	}
+	repo := openTestRepo(t, path)
+	if shallow, err := repo.repo.Storer.Shallow(); err != nil || len(shallow) == 0 {
+		t.Errorf("mirror synced for a range is not shallow: %v, %v", shallow, err)
+	}
+	if _, err := GetCommitHash(repo, "feature"); err == nil {
+		t.Errorf("mirror synced for a range has the unrelated branch feature")
+	}
+	if commits, err := ListCommitRange(ctx, repo, "HEAD~2", "HEAD"); err != nil || len(commits) != 2 {
+		t.Errorf("ListCommitRange() = %d commits, %v, want 2", len(commits), err)
+	}
+
+	// Syncing without a range completes it
+	opts.Start, opts.End = "", ""
+	if _, err := SyncMirror(ctx, opts); err != nil {
+		t.Fatalf("SyncMirror() without a range error = %v", err)
+	}
+	repo = openTestRepo(t, path)
+	if shallow, err := repo.repo.Storer.Shallow(); err != nil || len(shallow) != 0 {
+		t.Errorf("completed mirror is still shallow: %v, %v", shallow, err)
+	}
+	if _, err := GetCommitHash(repo, "feature"); err != nil {
+		t.Errorf("completed mirror is missing feature: %v", err)
+	}
+	if commits, err := ListCommitRange(ctx, repo, hashes[0], "HEAD"); err != nil || len(commits) != 4 {
+		t.Errorf("ListCommitRange() over the full history = %d commits, %v, want 4", len(commits), err)
+	}
+}
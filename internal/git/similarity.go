@@ -0,0 +1,180 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// similarityIndex summarizes file content for similarity scoring exactly as
+// go-git's rename detection does (after JGit's SimilarityIndex), so the
+// reported percentage is the one compared with the rename threshold: the
+// content is split into lines, or 64-byte blocks for long lines, and the
+// bytes of each distinct chunk are counted under a hash of the chunk
+type similarityIndex struct {
+	counts map[int]uint64 // chunk key -> bytes
+	total  uint64
+}
+
+// blockSize is the largest chunk hashed as one unit
+const blockSize = 64
+
+// readBufferSize is the buffer go-git hashes blobs through; a CR is only
+// dropped from a CRLF pair when both bytes sit in the same buffer
+const readBufferSize = 4096
+
+// newSimilarityIndex builds the index for data
+func newSimilarityIndex(data []byte) *similarityIndex {
+	idx := &similarityIndex{counts: make(map[int]uint64)}
+	binary := isBinary(data)
+
+	pos, remaining := 0, len(data)
+	for remaining > 0 {
+		hash := 5381
+		var hashed uint64
+		n := 0
+		for {
+			c := data[pos]
+			pos++
+			n++
+
+			// Ignore CR in CRLF sequences so line ending changes still match
+			if !binary && c == '\r' && pos%readBufferSize != 0 && pos < len(data) && data[pos] == '\n' {
+				continue
+			}
+			hashed++
+			if c == '\n' {
+				break
+			}
+			hash = (hash << 5) + hash + int(c)
+			if n >= blockSize || n >= remaining {
+				break
+			}
+		}
+
+		idx.counts[int(uint32(hash)*0x9e370001>>1)] += hashed
+		idx.total += hashed
+		remaining -= n
+	}
+
+	return idx
+}
+
+// score returns the similarity of two indexes on a scale of 0 to maxScore:
+// the bytes in chunks common to both divided by the size of the larger file
+func (idx *similarityIndex) score(other *similarityIndex, maxScore int) int {
+	largest := idx.total
+	if other.total > largest {
+		largest = other.total
+	}
+	if largest == 0 {
+		return maxScore // Two empty files are identical
+	}
+
+	var common uint64
+	for key, count := range idx.counts {
+		if otherCount, ok := other.counts[key]; ok {
+			if otherCount < count {
+				count = otherCount
+			}
+			common += count
+		}
+	}
+
+	return int(common * uint64(maxScore) / largest)
+}
+
+// renameScore returns the percentage go-git pairs a rename by: 99% content
+// similarity and 1% path similarity
+func renameScore(fromPath string, from *similarityIndex, toPath string, to *similarityIndex) int {
+	return (from.score(to, 10000)*99 + nameSimilarity(fromPath, toPath)*100) / 10000
+}
+
+// nameSimilarity scores two paths from 0 to 100 by their common directory
+// prefix and suffix and their common file name suffix, as go-git does
+func nameSimilarity(a, b string) int {
+	aDirLen := strings.LastIndexByte(a, '/') + 1
+	bDirLen := strings.LastIndexByte(b, '/') + 1
+	dirMin, dirMax := min(aDirLen, bDirLen), max(aDirLen, bDirLen)
+
+	dirScoreLtr, dirScoreRtl := 100, 100
+	if dirMax > 0 {
+		sim := 0
+		for sim < dirMin && a[sim] == b[sim] {
+			sim++
+		}
+		dirScoreLtr = sim * 100 / dirMax
+
+		if dirScoreLtr != 100 {
+			sim = 0
+			for sim < dirMin && a[aDirLen-1-sim] == b[bDirLen-1-sim] {
+				sim++
+			}
+			dirScoreRtl = sim * 100 / dirMax
+		}
+	}
+
+	fileMin, fileMax := min(len(a)-aDirLen, len(b)-bDirLen), max(len(a)-aDirLen, len(b)-bDirLen)
+	sim := 0
+	for sim < fileMin && a[len(a)-1-sim] == b[len(b)-1-sim] {
+		sim++
+	}
+	fileScore := 0
+	if fileMax > 0 {
+		fileScore = sim * 100 / fileMax
+	}
+
+	return ((dirScoreLtr+dirScoreRtl)*25 + fileScore*50) / 100
+}
+
+// isBinary applies git's heuristic: a NUL byte in the first 8000 bytes
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// blobSimilarity returns the similarity percentage of a rename from one blob
+// to another, as scored by go-git when it paired them
+func blobSimilarity(repo *git.Repository, fromPath string, from plumbing.Hash, toPath string, to plumbing.Hash) (int, error) {
+	// Exact renames are paired by hash alone
+	if from == to {
+		return 100, nil
+	}
+
+	fromIdx, err := blobSimilarityIndex(repo, from)
+	if err != nil {
+		return 0, err
+	}
+	toIdx, err := blobSimilarityIndex(repo, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return renameScore(fromPath, fromIdx, toPath, toIdx), nil
+}
+
+// blobSimilarityIndex reads a blob and builds its similarity index
+func blobSimilarityIndex(repo *git.Repository, hash plumbing.Hash) (*similarityIndex, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSimilarityIndex(data), nil
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/githubCompare/internal/testutil"
+)
+
+// TestRenameSimilarityMatchesThreshold checks that the reported similarity is
+// the score go-git paired the rename with: the rename is found at exactly that
+// threshold and lost one point above it
+func TestRenameSimilarityMatchesThreshold(t *testing.T) {
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d of the original file", i))
+	}
+	original := strings.Join(lines, "\n") + "\n"
+	lines[3], lines[17], lines[25] = "changed", "changed too", "and this\r"
+	edited := strings.Join(lines, "\r\n") + "\r\n"
+
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"src/old_name.go": original},
+		map[string]string{"src/old_name.go": "", "lib/new_name.go": edited}, // Moved and edited
+	)
+	repo := openTestRepo(t, dir)
+	ctx := context.Background()
+
+	renameAt := func(threshold int) *FileChange {
+		opts := DefaultDiffOptions
+		opts.RenameThreshold = threshold
+		changes, err := GetChangedFiles(ctx, repo, hashes[0], hashes[1], opts)
+		if err != nil {
+			t.Fatalf("GetChangedFiles() error = %v", err)
+		}
+		for i := range changes {
+			if changes[i].ChangeType == "renamed" {
+				return &changes[i]
+			}
+		}
+		return nil
+	}
+
+	rename := renameAt(50)
+	if rename == nil {
+		t.Fatalf("no rename detected at the default threshold")
+	}
+	similarity := rename.Similarity
+	if similarity <= 50 || similarity >= 100 {
+		t.Fatalf("similarity = %d, want a partial match above 50", similarity)
+	}
+	if renameAt(similarity) == nil {
+		t.Errorf("rename not detected with --rename-threshold %d, its own similarity", similarity)
+	}
+	if renameAt(similarity+1) != nil {
+		t.Errorf("rename still detected with --rename-threshold %d, above its similarity", similarity+1)
+	}
+}
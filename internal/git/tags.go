@@ -0,0 +1,157 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/mod/semver"
+)
+
+// ListTags lists all tags that point at commits, newest version first
+func ListTags(r *Repository) ([]Tag, error) {
+	repo := r.repo
+
+	tagIter, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	}
+
+	tags := []Tag{}
+	err = tagIter.ForEach(func(ref *plumbing.Reference) error {
+		tag := Tag{Name: ref.Name().Short()}
+
+		// Annotated tags have their own object; lightweight tags point
+		// straight at a commit
+		if tagObject, err := repo.TagObject(ref.Hash()); err == nil {
+			commit, err := tagObject.Commit()
+			if err != nil {
+				return nil // Tags of trees or blobs can't be compared
+			}
+			tag.Hash = commit.Hash.String()
+			tag.Annotated = true
+			tag.Tagger = tagObject.Tagger.Name
+			tag.Date = tagObject.Tagger.When
+			tag.Message = strings.TrimSpace(tagObject.Message)
+		} else {
+			commit, err := repo.CommitObject(ref.Hash())
+			if err != nil {
+				return nil
+			}
+			tag.Hash = commit.Hash.String()
+			tag.Date = commit.Author.When
+		}
+
+		tags = append(tags, tag)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process tags: %w", err)
+	}
+
+	SortTags(tags)
+	return tags, nil
+}
+
+// SortTags orders tags in place: semantic versions first, highest first,
+// then all other tags newest first
+func SortTags(tags []Tag) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		vi, vj := tagVersion(tags[i].Name), tagVersion(tags[j].Name)
+		switch {
+		case vi != "" && vj != "":
+			if c := semver.Compare(vi, vj); c != 0 {
+				return c > 0
+			}
+			return tags[i].Name < tags[j].Name
+		case vi != "" || vj != "":
+			return vi != ""
+		}
+		return tags[i].Date.After(tags[j].Date)
+	})
+}
+
+// LatestTag returns the highest released (non-prerelease) version tag
+func LatestTag(tags []Tag) (Tag, error) {
+	for _, tag := range sortedVersions(tags) {
+		if semver.Prerelease(tagVersion(tag.Name)) == "" {
+			return tag, nil
+		}
+	}
+	return Tag{}, &NotFoundError{Kind: "tag", Name: "release version"}
+}
+
+// PreviousTag returns the highest version tag below name. Pre-releases are
+// skipped unless name is a pre-release itself, so v1.1.0 follows v1.0.0
+// rather than v1.1.0-rc.1.
+func PreviousTag(tags []Tag, name string) (Tag, error) {
+	version := tagVersion(name)
+	if version == "" {
+		return Tag{}, fmt.Errorf("tag %s is not a semantic version", name)
+	}
+	withPrereleases := semver.Prerelease(version) != ""
+
+	for _, tag := range sortedVersions(tags) {
+		candidate := tagVersion(tag.Name)
+		if semver.Compare(candidate, version) >= 0 {
+			continue
+		}
+		if !withPrereleases && semver.Prerelease(candidate) != "" {
+			continue
+		}
+		return tag, nil
+	}
+
+	return Tag{}, &NotFoundError{Kind: "tag", Name: "version before " + name}
+}
+
+// sortedVersions returns the semantic version tags, highest first
+func sortedVersions(tags []Tag) []Tag {
+	versions := []Tag{}
+	for _, tag := range tags {
+		if tagVersion(tag.Name) != "" {
+			versions = append(versions, tag)
+		}
+	}
+	SortTags(versions)
+	return versions
+}
+
+// tagVersion returns the tag name as a canonical semver string with a "v"
+// prefix, or "" if it is not a semantic version
+func tagVersion(name string) string {
+	version := name
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if !semver.IsValid(version) {
+		return ""
+	}
+	return version
+}
+
+// labelTags sets the names of the tags pointing at each commit
+func labelTags(r *Repository, commits []Commit) {
+	byCommit := commitTags(r)
+	for i := range commits {
+		commits[i].Tags = byCommit[commits[i].Hash]
+	}
+}
+
+// commitTags returns the names of the tags pointing at each commit, listed
+// once per handle so paging through a history peels every tag only once.
+// Tags are only labels here, so failing to list them is not an error.
+func commitTags(r *Repository) map[string][]string {
+	r.tagsOnce.Do(func() {
+		tags, err := ListTags(r)
+		if err != nil {
+			return
+		}
+		r.tagNames = make(map[string][]string)
+		for _, tag := range tags {
+			r.tagNames[tag.Hash] = append(r.tagNames[tag.Hash], tag.Name)
+		}
+	})
+	return r.tagNames
+}
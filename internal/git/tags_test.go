@@ -0,0 +1,138 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/githubCompare/internal/testutil"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestListTags(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+	)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", plumbing.NewHash(hashes[0]), nil); err != nil {
+		t.Fatalf("Failed to create lightweight tag: %v", err)
+	}
+	tagged := time.Date(2026, 2, 1, 9, 30, 0, 0, time.UTC)
+	annotated, err := repo.CreateTag("v1.1.0", plumbing.NewHash(hashes[1]), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Releaser", Email: "releaser@example.com", When: tagged},
+		Message: "Release 1.1.0\n",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create annotated tag: %v", err)
+	}
+
+	tags, err := ListTags(openTestRepo(t, dir))
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("ListTags() returned %d tags, want 2: %+v", len(tags), tags)
+	}
+
+	// The annotated tag is peeled to its commit, not the tag object
+	got := tags[0]
+	if got.Name != "v1.1.0" || got.Hash != hashes[1] || !got.Annotated {
+		t.Errorf("tags[0] = %+v, want annotated v1.1.0 at %s", got, hashes[1])
+	}
+	if got.Hash == annotated.Hash().String() {
+		t.Errorf("tags[0].Hash is the tag object %s, want the commit", got.Hash)
+	}
+	if got.Tagger != "Releaser" || !got.Date.Equal(tagged) || got.Message != "Release 1.1.0" {
+		t.Errorf("tags[0] tagger, date, message = %q, %s, %q, want %q, %s, %q", got.Tagger, got.Date, got.Message, "Releaser", tagged, "Release 1.1.0")
+	}
+
+	// A lightweight tag has no tagger or message and takes the commit date
+	got = tags[1]
+	commitDate := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got.Name != "v1.0.0" || got.Hash != hashes[0] || got.Annotated {
+		t.Errorf("tags[1] = %+v, want lightweight v1.0.0 at %s", got, hashes[0])
+	}
+	if got.Tagger != "" || got.Message != "" || !got.Date.Equal(commitDate) {
+		t.Errorf("tags[1] tagger, date, message = %q, %s, %q, want none and %s", got.Tagger, got.Date, got.Message, commitDate)
+	}
+}
+
+func TestLabelTagsOncePerHandle(t *testing.T) {
+	dir, hashes := testutil.NewFixtureRepo(t,
+		map[string]string{"a.txt": "one\n"},
+		map[string]string{"a.txt": "two\n"},
+	)
+	source, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	if _, err := source.CreateTag("v1.0.0", plumbing.NewHash(hashes[0]), nil); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+
+	repo := openTestRepo(t, dir)
+	commits := []Commit{{Hash: hashes[1]}, {Hash: hashes[0]}}
+	labelTags(repo, commits)
+	if len(commits[0].Tags) != 0 || len(commits[1].Tags) != 1 || commits[1].Tags[0] != "v1.0.0" {
+		t.Errorf("labelTags() = %v, %v, want none and [v1.0.0]", commits[0].Tags, commits[1].Tags)
+	}
+
+	// Later pages reuse the tags listed for the first one
+	if _, err := source.CreateTag("v1.1.0", plumbing.NewHash(hashes[1]), nil); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+	labelTags(repo, commits)
+	if len(commits[0].Tags) != 0 {
+		t.Errorf("labelTags() listed the tags again: %v", commits[0].Tags)
+	}
+}
+
+func TestPreviousTag(t *testing.T) {
+	tags := []Tag{
+		{Name: "v1.0.0"},
+		{Name: "v1.10.0"},
+		{Name: "v1.2.0"},
+		{Name: "v1.2.0-rc.1"},
+		{Name: "2.0.0"},
+		{Name: "nightly"},
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"v1.10.0", "v1.2.0", false},
+		{"2.0.0", "v1.10.0", false},
+		{"v1.2.0", "v1.0.0", false},
+		{"v1.2.0-rc.1", "v1.0.0", false},
+		{"v1.0.0", "", true},
+		{"nightly", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := PreviousTag(tags, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("PreviousTag(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got.Name != tt.want {
+			t.Errorf("PreviousTag(%s) = %s, want %s", tt.name, got.Name, tt.want)
+		}
+	}
+
+	latest, err := LatestTag(tags)
+	if err != nil || latest.Name != "2.0.0" {
+		t.Errorf("LatestTag() = %s, %v, want 2.0.0", latest.Name, err)
+	}
+
+	SortTags(tags)
+	if tags[0].Name != "2.0.0" || tags[len(tags)-1].Name != "nightly" {
+		t.Errorf("SortTags() = %v, want versions first, highest first", tags)
+	}
+}
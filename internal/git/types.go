@@ -1,27 +1,103 @@
 package git
 
-import "time"
+import (
+	"time"
+
+	"github.com/githubCompare/internal/utils"
+)
 
 // Branch represents a Git branch
 type Branch struct {
-	Name         string
-	IsRemote     bool
-	IsHead       bool
-	LastCommit   *Commit // Last commit on this branch
+	Name       string      `json:"name" yaml:"name"`
+	IsRemote   bool        `json:"remote" yaml:"remote"`
+	IsHead     bool        `json:"head" yaml:"head"`
+	LastCommit *Commit     `json:"last_commit,omitempty" yaml:"last_commit,omitempty"` // Last commit on this branch
+	Divergence *Divergence `json:"divergence,omitempty" yaml:"divergence,omitempty"`   // Set by CountAheadBehind
+}
+
+// Divergence counts the commits a branch has that a base branch lacks, and
+// the other way round
+type Divergence struct {
+	Base   string `json:"base" yaml:"base"`
+	Ahead  int    `json:"ahead" yaml:"ahead"`
+	Behind int    `json:"behind" yaml:"behind"`
 }
 
 // Commit represents a Git commit
 type Commit struct {
-	Hash      string
-	ShortHash string
-	Message   string
-	Author    string
-	Date      time.Time
+	Hash      string    `json:"hash" yaml:"hash"`
+	ShortHash string    `json:"short_hash" yaml:"short_hash"`
+	Message   string    `json:"message" yaml:"message"`
+	Body      string    `json:"body,omitempty" yaml:"body,omitempty"` // Message after the subject line, when listed for a range
+	Author    string    `json:"author" yaml:"author"`
+	Date      time.Time `json:"date" yaml:"date"`
+	Tags      []string  `json:"tags,omitempty" yaml:"tags,omitempty"` // Tags pointing at this commit, when listed for a branch
+}
+
+// Tag represents a lightweight or annotated Git tag
+type Tag struct {
+	Name      string    `json:"name" yaml:"name"`
+	Hash      string    `json:"hash" yaml:"hash"` // Commit the tag points at
+	Annotated bool      `json:"annotated" yaml:"annotated"`
+	Tagger    string    `json:"tagger,omitempty" yaml:"tagger,omitempty"`   // Annotated tags only
+	Message   string    `json:"message,omitempty" yaml:"message,omitempty"` // Annotated tags only
+	Date      time.Time `json:"date" yaml:"date"`                           // Tagging date, or the commit date for lightweight tags
 }
 
 // FileChange represents a changed file
 type FileChange struct {
-	Path       string
-	ChangeType string // "added", "modified", "deleted", "renamed"
-	OldPath    string // for renames
+	Path       string `json:"path" yaml:"path"`
+	ChangeType string `json:"type" yaml:"type"`                                 // "added", "modified", "deleted", "renamed", "copied"
+	OldPath    string `json:"old_path,omitempty" yaml:"old_path,omitempty"`     // for renames and copies
+	Similarity int    `json:"similarity,omitempty" yaml:"similarity,omitempty"` // Percentage, for renames and copies
+	OldSize    int64  `json:"old_size" yaml:"old_size"`                         // Blob size in the start commit
+	Size       int64  `json:"size" yaml:"size"`                                 // Blob size in the end commit
+	OldMode    string `json:"old_mode,omitempty" yaml:"old_mode,omitempty"`     // Git file mode in the start commit, e.g. "0100644"
+	Mode       string `json:"mode,omitempty" yaml:"mode,omitempty"`             // Git file mode in the end commit
+	Additions  int    `json:"additions" yaml:"additions"`                       // Lines added, when line stats are enabled
+	Deletions  int    `json:"deletions" yaml:"deletions"`                       // Lines removed, when line stats are enabled
+	Binary     bool   `json:"binary,omitempty" yaml:"binary,omitempty"`         // Content is binary, so no line counts
+}
+
+// DiffOptions controls how changes between two commits are detected
+type DiffOptions struct {
+	DetectRenames   bool // Pair deleted and added files into renames
+	DetectCopies    bool // Report added files that copy an existing file
+	RenameThreshold int  // Minimum similarity percentage for renames and copies
+	LineStats       bool // Count added and removed lines per file
+}
+
+// Mirror represents a cached bare mirror of a remote repository
+type Mirror struct {
+	Path     string
+	URL      string
+	Size     int64     // Bytes on disk
+	LastUsed time.Time // Last time the mirror was synced
+}
+
+// ResolvedRef pairs a reference as given by the caller with the commit it resolved to
+type ResolvedRef struct {
+	Ref  string `json:"ref" yaml:"ref"`
+	Hash string `json:"hash" yaml:"hash"`
+}
+
+// Result is the outcome of comparing two references
+type Result struct {
+	Repository   *utils.RepoInfo `json:"repository" yaml:"repository"`
+	Start        ResolvedRef     `json:"start" yaml:"start"`
+	End          ResolvedRef     `json:"end" yaml:"end"`
+	MergeBase    string          `json:"merge_base,omitempty" yaml:"merge_base,omitempty"` // Commit the changes are relative to, in merge-base mode
+	Commits      []Commit        `json:"commits" yaml:"commits"`                           // Newest first
+	Changes      []FileChange    `json:"changes" yaml:"changes"`                           // After path filtering
+	TotalChanges int             `json:"-" yaml:"-"`                                       // Changed files before path filtering
+	Archive      string          `json:"archive,omitempty" yaml:"archive,omitempty"`       // Absolute path of the created ZIP
+}
+
+// Base returns the reference the changes are relative to: the merge base in
+// merge-base mode, the start reference otherwise
+func (r *Result) Base() string {
+	if r.MergeBase != "" {
+		return r.MergeBase
+	}
+	return r.Start.Ref
 }
@@ -0,0 +1,10 @@
+// Package handle gives the commands and the tui the repository opened by a
+// compare.Comparer, which pkg/compare keeps out of its public API as the
+// type lives in internal/git.
+package handle
+
+import "github.com/githubCompare/internal/git"
+
+// Repo returns the repository opened by c, a *compare.Comparer, or nil
+// before it is opened. pkg/compare sets it when it is initialised.
+var Repo func(c any) *git.Repository
@@ -12,7 +12,7 @@ func ConfirmAction(message string) (bool, error) {
 		Default: true,
 	}
 
-	if err := survey.AskOne(prompt, &confirmed); err != nil {
+	if err := survey.AskOne(prompt, &confirmed, surveyStdio()); err != nil {
 		return false, err
 	}
 
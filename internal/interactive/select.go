@@ -2,89 +2,174 @@ package interactive
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/githubCompare/internal/display"
 	"github.com/githubCompare/internal/git"
 )
 
-// SelectBranch prompts the user to select a branch
-func SelectBranch(branches []git.Branch) (string, error) {
-	if len(branches) == 0 {
-		return "", fmt.Errorf("no branches available")
+// SelectRef prompts the user to select a branch or a tag
+func SelectRef(branches []git.Branch, tags []git.Tag) (string, error) {
+	if len(branches) == 0 && len(tags) == 0 {
+		return "", fmt.Errorf("no branches or tags available")
 	}
 
-	// Display branches nicely
+	// Display branches and tags nicely
 	display.PrintBranches(branches)
+	if len(tags) > 0 {
+		display.PrintTags(tags)
+	}
 
-	options := make([]string, len(branches))
+	options := make([]string, 0, len(branches)+len(tags))
 	for i, branch := range branches {
-		options[i] = display.FormatBranchOption(branch, i)
+		options = append(options, display.FormatBranchOption(branch, i))
+	}
+	for i, tag := range tags {
+		options = append(options, display.FormatTagOption(tag, len(branches)+i))
 	}
 
-	var selected string
+	var selected int
 	prompt := &survey.Select{
-		Message: "Select a branch:",
+		Message: "Select a branch or tag:",
 		Options: options,
 		PageSize: 15,
 	}
 
-	if err := survey.AskOne(prompt, &selected); err != nil {
+	if err := survey.AskOne(prompt, &selected, surveyStdio()); err != nil {
 		return "", fmt.Errorf("failed to select branch: %w", err)
 	}
 
-	// Extract branch name (get the branch name from the formatted option)
-	parts := strings.Fields(selected)
-	if len(parts) >= 2 {
-		// Find the branch name (skip index, arrow if present)
-		for i, part := range parts {
-			if i > 0 && !strings.HasSuffix(part, ".") && part != "→" && part != "(remote)" && part != "(current" && part != "HEAD)" {
-				return part, nil
-			}
-		}
+	if selected < len(branches) {
+		return branches[selected].Name, nil
+	}
+	return tags[selected-len(branches)].Name, nil
+}
+
+// surveyStdio renders prompts on the same stream as the rest of the
+// human-readable output, keeping stdout clean for machine-readable data
+func surveyStdio() survey.AskOpt {
+	return survey.WithStdio(os.Stdin, display.Output(), os.Stderr)
+}
+
+// commitPageSize is how many commits are loaded each time the user asks for more
+const commitPageSize = 100
+
+// CommitLoader returns up to limit commits of a history after skipping the
+// first skip
+type CommitLoader func(skip, limit int) ([]git.Commit, error)
+
+// CommitList is a branch history that is loaded a page at a time, shared by
+// the start and end prompts so pages are only loaded once
+type CommitList struct {
+	load    CommitLoader
+	commits []git.Commit
+	done    bool
+}
+
+// NewCommitList creates a history that loads its pages with load
+func NewCommitList(load CommitLoader) *CommitList {
+	return &CommitList{load: load}
+}
+
+// LoadMore loads the next page of commits
+func (l *CommitList) LoadMore() error {
+	if l.done {
+		return nil
+	}
+	page, err := l.load(len(l.commits), commitPageSize)
+	if err != nil {
+		return err
 	}
+	l.commits = append(l.commits, page...)
+	l.done = len(page) < commitPageSize
+	return nil
+}
 
-	return "", fmt.Errorf("failed to parse selected branch")
+// Commits returns the commits loaded so far, newest first
+func (l *CommitList) Commits() []git.Commit {
+	return l.commits
 }
 
-// SelectCommit prompts the user to select a commit
-func SelectCommit(commits []git.Commit, promptText string) (string, error) {
-	if len(commits) == 0 {
+// SelectCommit prompts the user to select a commit from history, loading the
+// first page if needed. Typing filters the list fuzzily on hash, author,
+// message and tags; a final "load more" entry fetches the next page.
+func SelectCommit(history *CommitList, promptText string) (string, error) {
+	if len(history.commits) == 0 {
+		if err := history.LoadMore(); err != nil {
+			return "", fmt.Errorf("failed to load commits: %w", err)
+		}
+	}
+	if len(history.commits) == 0 {
 		return "", fmt.Errorf("no commits available")
 	}
 
 	// Display commits nicely
-	display.PrintCommits(commits, 20)
+	display.PrintCommits(history.commits, 20)
+
+	cursor := 0
+	for {
+		commits := history.commits
+		options := make([]string, len(commits), len(commits)+1)
+		for i, commit := range commits {
+			options[i] = display.FormatCommitOption(commit, i)
+		}
+		if !history.done {
+			options = append(options, fmt.Sprintf("… load %d more commits", commitPageSize))
+		}
 
-	options := make([]string, len(commits))
-	for i, commit := range commits {
-		options[i] = display.FormatCommitOption(commit, i)
-	}
+		var selected int
+		prompt := &survey.Select{
+			Message:  promptText,
+			Options:  options,
+			Default:  cursor,
+			PageSize: 15,
+			Filter: func(filter, value string, index int) bool {
+				// Keep "load more" visible when nothing loaded matches
+				if index >= len(commits) {
+					return true
+				}
+				return fuzzyMatch(filter, commitSearchText(commits[index]))
+			},
+		}
 
-	var selected string
-	prompt := &survey.Select{
-		Message: promptText,
-		Options: options,
-		PageSize: 15,
-	}
+		if err := survey.AskOne(prompt, &selected, surveyStdio()); err != nil {
+			return "", fmt.Errorf("failed to select commit: %w", err)
+		}
+		if selected < len(commits) {
+			return commits[selected].Hash, nil
+		}
 
-	if err := survey.AskOne(prompt, &selected); err != nil {
-		return "", fmt.Errorf("failed to select commit: %w", err)
+		if err := history.LoadMore(); err != nil {
+			return "", fmt.Errorf("failed to load more commits: %w", err)
+		}
+		cursor = len(commits)
+		if cursor >= len(history.commits) {
+			cursor = len(history.commits) - 1
+		}
 	}
+}
+
+// commitSearchText is what the commit filter matches against
+func commitSearchText(commit git.Commit) string {
+	return strings.Join(append([]string{commit.Hash, commit.Author, commit.Message}, commit.Tags...), " ")
+}
 
-	// Extract commit hash (first field after the number)
-	parts := strings.Fields(selected)
-	if len(parts) >= 2 {
-		hash := parts[1] // Skip the index number
-		// Find full hash
-		for _, commit := range commits {
-			if commit.ShortHash == hash {
-				return commit.Hash, nil
+// fuzzyMatch reports whether every space-separated term of filter appears in
+// text as a case-insensitive subsequence, so "fx lgn" matches "fix login"
+func fuzzyMatch(filter, text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(filter)) {
+		rest := text
+		for _, r := range term {
+			i := strings.IndexRune(rest, r)
+			if i < 0 {
+				return false
 			}
+			rest = rest[i+utf8.RuneLen(r):]
 		}
-		return hash, nil
 	}
-
-	return "", fmt.Errorf("failed to parse selected commit")
+	return true
 }
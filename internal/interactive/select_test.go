@@ -0,0 +1,27 @@
+package interactive
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		text   string
+		want   bool
+	}{
+		{"", "anything", true},
+		{"login", "4e936df Alice fix login redirect", true},
+		{"fx lgn", "4e936df Alice fix login redirect", true},
+		{"ALICE", "4e936df Alice fix login redirect", true},
+		{"4e93", "4e936df Alice fix login redirect", true},
+		{"lgn fx", "4e936df Alice fix login redirect", true},
+		{"xif", "4e936df Alice fix login redirect", false},
+		{"bob", "4e936df Alice fix login redirect", false},
+		{"é", "résumé", true},
+	}
+
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.filter, tt.text); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.filter, tt.text, got, tt.want)
+		}
+	}
+}
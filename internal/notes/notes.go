@@ -0,0 +1,228 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/githubCompare/internal/git"
+)
+
+// Entry is a commit parsed as a Conventional Commit
+type Entry struct {
+	Type         string `json:"type"` // Lower-case commit type, "" when the subject is not conventional
+	Scope        string `json:"scope,omitempty"`
+	Description  string `json:"description"`
+	Breaking     bool   `json:"breaking,omitempty"`
+	BreakingNote string `json:"breaking_note,omitempty"` // From a BREAKING CHANGE footer, or the description for "type!:"
+	Hash         string `json:"hash"`
+	ShortHash    string `json:"short_hash"`
+	Author       string `json:"author"`
+	URL          string `json:"url,omitempty"` // Commit page on the repository host
+}
+
+// Section groups the entries of one commit type
+type Section struct {
+	Type    string  `json:"type"`
+	Title   string  `json:"title"`
+	Entries []Entry `json:"entries"`
+}
+
+// Notes are the release notes for a range of commits
+type Notes struct {
+	Repository string    `json:"repository"`
+	Start      string    `json:"start"`
+	End        string    `json:"end"`
+	CompareURL string    `json:"compare_url,omitempty"`
+	Breaking   []Entry   `json:"breaking"`
+	Sections   []Section `json:"sections"`
+}
+
+// Options controls which commits end up in the notes and how they are linked
+type Options struct {
+	All    bool   // Include every commit type, and commits that are not conventional
+	WebURL string // Browsable repository address used for links; "" for no links
+}
+
+// sectionTitles lists the known commit types in the order they are rendered
+var sectionTitles = []struct{ typ, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"refactor", "Code Refactoring"},
+	{"style", "Styles"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"chore", "Chores"},
+	{"", "Other Changes"},
+}
+
+// defaultTypes are the commit types shown without Options.All
+var defaultTypes = map[string]bool{"feat": true, "fix": true, "perf": true, "revert": true}
+
+var (
+	subjectPattern  = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+	breakingPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s*`)
+)
+
+// Parse splits a commit subject of the form "type(scope)!: description" and
+// looks for a BREAKING CHANGE footer in the body
+func Parse(commit git.Commit) Entry {
+	entry := Entry{
+		Description: commit.Message,
+		Hash:        commit.Hash,
+		ShortHash:   commit.ShortHash,
+		Author:      commit.Author,
+	}
+
+	matches := subjectPattern.FindStringSubmatch(commit.Message)
+	if matches == nil {
+		return entry
+	}
+	entry.Type = strings.ToLower(matches[1])
+	entry.Scope = matches[2]
+	entry.Description = matches[4]
+	entry.Breaking = matches[3] == "!"
+
+	// The footer runs to the next blank line
+	if loc := breakingPattern.FindStringIndex(commit.Body); loc != nil {
+		note, _, _ := strings.Cut(commit.Body[loc[1]:], "\n\n")
+		entry.Breaking = true
+		entry.BreakingNote = strings.Join(strings.Fields(note), " ")
+	}
+	if entry.Breaking && entry.BreakingNote == "" {
+		entry.BreakingNote = entry.Description
+	}
+
+	return entry
+}
+
+// New builds release notes from the commits of a range, newest first
+func New(repository, start, end string, commits []git.Commit, opts Options) *Notes {
+	notes := &Notes{
+		Repository: repository,
+		Start:      start,
+		End:        end,
+		Breaking:   []Entry{},
+		Sections:   []Section{},
+	}
+	if opts.WebURL != "" {
+		notes.CompareURL = compareURL(opts.WebURL, start, end)
+	}
+
+	known := make(map[string]bool)
+	for _, section := range sectionTitles {
+		known[section.typ] = true
+	}
+
+	byType := make(map[string][]Entry)
+	for _, commit := range commits {
+		entry := Parse(commit)
+		if opts.WebURL != "" {
+			entry.URL = commitURL(opts.WebURL, entry.Hash)
+		}
+		if entry.Breaking {
+			notes.Breaking = append(notes.Breaking, entry)
+		}
+
+		// Unknown types are filed with the non-conventional commits
+		sectionType := entry.Type
+		if !known[sectionType] {
+			sectionType = ""
+		}
+		if !opts.All && !defaultTypes[sectionType] {
+			continue
+		}
+		byType[sectionType] = append(byType[sectionType], entry)
+	}
+
+	for _, section := range sectionTitles {
+		if entries := byType[section.typ]; len(entries) > 0 {
+			notes.Sections = append(notes.Sections, Section{Type: section.typ, Title: section.title, Entries: entries})
+		}
+	}
+
+	return notes
+}
+
+// commitURL links a commit on the repository host
+func commitURL(webURL, hash string) string {
+	switch {
+	case strings.Contains(webURL, "://gitlab."):
+		return webURL + "/-/commit/" + hash
+	case strings.Contains(webURL, "://bitbucket.org/"):
+		return webURL + "/commits/" + hash
+	}
+	return webURL + "/commit/" + hash
+}
+
+// compareURL links the range on the repository host, or returns "" for hosts
+// without a compare view
+func compareURL(webURL, start, end string) string {
+	switch {
+	case strings.Contains(webURL, "://gitlab."):
+		return webURL + "/-/compare/" + start + "..." + end
+	case strings.Contains(webURL, "://bitbucket.org/"):
+		return ""
+	}
+	return webURL + "/compare/" + start + "..." + end
+}
+
+// JSON returns the indented JSON encoding of the notes
+func (n *Notes) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode release notes: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Markdown renders the notes in the style of conventional-changelog
+func (n *Notes) Markdown() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Release Notes for %s\n\n", n.Repository)
+	fmt.Fprintf(&b, "Changes from `%s` to `%s`", n.Start, n.End)
+	if n.CompareURL != "" {
+		fmt.Fprintf(&b, " ([compare](%s))", n.CompareURL)
+	}
+	b.WriteString(".\n")
+
+	if len(n.Breaking) > 0 {
+		b.WriteString("\n## ⚠ BREAKING CHANGES\n\n")
+		for _, entry := range n.Breaking {
+			writeEntry(&b, entry, entry.BreakingNote)
+		}
+	}
+
+	for _, section := range n.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.Title)
+		for _, entry := range section.Entries {
+			writeEntry(&b, entry, entry.Description)
+		}
+	}
+
+	if len(n.Breaking) == 0 && len(n.Sections) == 0 {
+		b.WriteString("\nNo notable changes.\n")
+	}
+
+	return []byte(b.String())
+}
+
+// writeEntry writes one list item with its scope and commit link
+func writeEntry(b *strings.Builder, entry Entry, text string) {
+	b.WriteString("- ")
+	if entry.Scope != "" {
+		fmt.Fprintf(b, "**%s:** ", entry.Scope)
+	}
+	b.WriteString(text)
+	if entry.URL != "" {
+		fmt.Fprintf(b, " ([%s](%s))", entry.ShortHash, entry.URL)
+	} else {
+		fmt.Fprintf(b, " (%s)", entry.ShortHash)
+	}
+	b.WriteString("\n")
+}
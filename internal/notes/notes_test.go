@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/githubCompare/internal/git"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		message, body string
+		want          Entry
+	}{
+		{"feat: add export", "", Entry{Type: "feat", Description: "add export"}},
+		{"fix(api): handle nil", "", Entry{Type: "fix", Scope: "api", Description: "handle nil"}},
+		{"Feat(ui)!: new layout", "", Entry{Type: "feat", Scope: "ui", Description: "new layout", Breaking: true, BreakingNote: "new layout"}},
+		{"refactor: drop v1", "Details.\n\nBREAKING CHANGE: the v1 API\nis gone\n\nRefs: #12", Entry{Type: "refactor", Description: "drop v1", Breaking: true, BreakingNote: "the v1 API is gone"}},
+		{"Merge branch 'main'", "", Entry{Description: "Merge branch 'main'"}},
+	}
+
+	for _, tt := range tests {
+		got := Parse(git.Commit{Message: tt.message, Body: tt.body})
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestNewGroupsAndLinks(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "c4", ShortHash: "c4", Message: "chore: bump deps"},
+		{Hash: "c3", ShortHash: "c3", Message: "fix(core): off by one"},
+		{Hash: "c2", ShortHash: "c2", Message: "feat!: new config format"},
+		{Hash: "c1", ShortHash: "c1", Message: "wip"},
+	}
+
+	notes := New("repo", "v1.0.0", "v1.1.0", commits, Options{WebURL: "https://github.com/o/r"})
+	if notes.CompareURL != "https://github.com/o/r/compare/v1.0.0...v1.1.0" {
+		t.Errorf("CompareURL = %q", notes.CompareURL)
+	}
+
+	titles := []string{}
+	for _, section := range notes.Sections {
+		titles = append(titles, section.Title)
+	}
+	if strings.Join(titles, ",") != "Features,Bug Fixes" {
+		t.Errorf("Sections = %v, want Features and Bug Fixes only", titles)
+	}
+	if len(notes.Breaking) != 1 || notes.Breaking[0].URL != "https://github.com/o/r/commit/c2" {
+		t.Errorf("Breaking = %+v, want the linked feat! commit", notes.Breaking)
+	}
+
+	all := New("repo", "a", "b", commits, Options{All: true})
+	if len(all.Sections) != 4 || all.Sections[3].Title != "Other Changes" {
+		t.Errorf("Sections with All = %+v, want 4 ending with Other Changes", all.Sections)
+	}
+
+	markdown := string(notes.Markdown())
+	for _, want := range []string{"## ⚠ BREAKING CHANGES", "- **core:** off by one ([c3](https://github.com/o/r/commit/c3))"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() is missing %q:\n%s", want, markdown)
+		}
+	}
+}
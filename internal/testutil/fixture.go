@@ -0,0 +1,103 @@
+// Package testutil builds the git repositories the tests run against
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// NewFixtureRepo creates a repository on disk with one commit per entry in
+// steps and returns its path and the commit hashes. Each step maps file paths
+// to contents; an empty content deletes the file.
+func NewFixtureRepo(t testing.TB, steps ...map[string]string) (string, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to init fixture repository: %v", err)
+	}
+
+	return dir, commitSteps(t, repo, dir, 0, steps)
+}
+
+// ForkFixtureRepo creates branch at the commit from in the fixture repository
+// at dir and commits steps on it, returning their hashes. The fixture's
+// original branch is checked out again afterwards.
+func ForkFixtureRepo(t testing.TB, dir, branch, from string, steps ...map[string]string) []string {
+	t.Helper()
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("Failed to open fixture repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(from), Branch: plumbing.NewBranchReferenceName(branch), Create: true})
+	if err != nil {
+		t.Fatalf("Failed to create branch %s: %v", branch, err)
+	}
+	// Later timestamps than the original branch, so the fork is the newest work
+	hashes := commitSteps(t, repo, dir, 10, steps)
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+		t.Fatalf("Failed to check out %s again: %v", head.Name(), err)
+	}
+	return hashes
+}
+
+// commitSteps commits each step on the checked out branch of repo,
+// numbering the commit times and messages from offset, and returns the hashes
+func commitSteps(t testing.TB, repo *git.Repository, dir string, offset int, steps []map[string]string) []string {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	hashes := []string{}
+	when := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, files := range steps {
+		for path, content := range files {
+			fullPath := filepath.Join(dir, path)
+			if content == "" {
+				if _, err := worktree.Remove(path); err != nil {
+					t.Fatalf("Failed to remove %s: %v", path, err)
+				}
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				t.Fatalf("Failed to create directory for %s: %v", path, err)
+			}
+			if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+			if _, err := worktree.Add(path); err != nil {
+				t.Fatalf("Failed to add %s: %v", path, err)
+			}
+		}
+
+		n := offset + i
+		signature := &object.Signature{Name: "Tester", Email: "tester@example.com", When: when.Add(time.Duration(n) * time.Hour)}
+		hash, err := worktree.Commit("commit "+string(rune('A'+n)), &git.CommitOptions{Author: signature, Committer: signature})
+		if err != nil {
+			t.Fatalf("Failed to commit step %d: %v", i, err)
+		}
+		hashes = append(hashes, hash.String())
+	}
+
+	return hashes
+}
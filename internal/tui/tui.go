@@ -0,0 +1,488 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/githubCompare/internal/git"
+)
+
+// commitPageSize is how many commits are loaded at a time as the commit list
+// is scrolled
+const commitPageSize = 100
+
+// Options configures the browser
+type Options struct {
+	Repo     *git.Repository
+	Title    string // Shown in the header, usually the repository URL
+	Branches []git.Branch
+	Tags     []git.Tag
+
+	// Compare lists the changes between two commits, honoring the same diff,
+	// filter and merge-base options as the compare command
+	Compare func(ctx context.Context, start, end string) (*git.Result, error)
+
+	// WritePatch writes the unified diff of the result's changes to w
+	WritePatch func(ctx context.Context, w io.Writer, result *git.Result) error
+
+	// Export writes an archive of the result's changes and returns its path
+	Export func(ctx context.Context, result *git.Result) (string, error)
+}
+
+// Run shows the browser until the user quits and returns the paths of the
+// archives exported along the way. Cancelling ctx closes the browser and
+// aborts the git work in progress.
+func Run(ctx context.Context, opts Options) ([]string, error) {
+	m := newModel(opts)
+	m.ctx = ctx
+	final, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run terminal UI: %w", err)
+	}
+	return final.(*model).exported, nil
+}
+
+// screen is one of the browser's views
+type screen int
+
+const (
+	refScreen screen = iota
+	commitScreen
+	fileScreen
+	diffScreen
+)
+
+// listState is the cursor and scroll position of a list
+type listState struct {
+	pos    int
+	offset int
+}
+
+// move moves the cursor by delta within a list of n rows, scrolling so it
+// stays within the visible rows
+func (l *listState) move(delta, n, rows int) {
+	l.pos += delta
+	if l.pos >= n {
+		l.pos = n - 1
+	}
+	if l.pos < 0 {
+		l.pos = 0
+	}
+	if l.pos < l.offset {
+		l.offset = l.pos
+	}
+	if rows > 0 && l.pos >= l.offset+rows {
+		l.offset = l.pos - rows + 1
+	}
+}
+
+// model is the state of the browser
+type model struct {
+	ctx           context.Context // Cancels the background git work
+	opts          Options
+	screen        screen
+	width, height int
+
+	refs    []string // Branch names, then tag names
+	refList listState
+
+	ref         string // Branch or tag whose commits are listed
+	commits     []git.Commit
+	commitsDone bool
+	commitList  listState
+	start, end  string // Marked commit hashes
+
+	result   *git.Result // Comparison of the marked commits
+	changes  []git.FileChange
+	excluded map[string]bool // Paths toggled out of the archive
+	fileList listState
+
+	diffPath  string
+	diffLines []string
+	diffList  listState
+
+	loading    bool
+	loadID     int                // Identifies the running load's messages
+	cancelLoad context.CancelFunc // Cancels the running load, nil if none
+	status     string
+	isError    bool
+	exported   []string
+}
+
+// Messages delivered by the commands that do the git work in the background
+type (
+	commitsMsg struct {
+		id      int
+		ref     string
+		commits []git.Commit
+		err     error
+	}
+	changesMsg struct {
+		id     int
+		result *git.Result
+		err    error
+	}
+	diffMsg struct {
+		id    int
+		path  string
+		lines []string
+		err   error
+	}
+	exportMsg struct {
+		path string
+		err  error
+	}
+)
+
+func newModel(opts Options) *model {
+	m := &model{ctx: context.Background(), opts: opts, excluded: make(map[string]bool)}
+	for _, branch := range opts.Branches {
+		m.refs = append(m.refs, branch.Name)
+	}
+	for _, tag := range opts.Tags {
+		m.refs = append(m.refs, tag.Name)
+	}
+	return m
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+// rows is how many list rows fit between the header and the footer
+func (m *model) rows() int {
+	if m.height <= 4 {
+		return 1
+	}
+	return m.height - 4
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case commitsMsg:
+		if !m.finishLoad(msg.id) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.setError(fmt.Sprintf("Failed to list commits: %v", msg.err))
+			return m, nil
+		}
+		if msg.ref != m.ref {
+			// A new branch was picked: start its list over
+			m.ref, m.commits, m.start, m.end = msg.ref, nil, "", ""
+			m.commitList = listState{}
+		}
+		m.commits = append(m.commits, msg.commits...)
+		m.commitsDone = len(msg.commits) < commitPageSize
+		m.screen = commitScreen
+		m.setStatus(fmt.Sprintf("%d commits loaded", len(m.commits)))
+		return m, nil
+
+	case changesMsg:
+		if !m.finishLoad(msg.id) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+			return m, nil
+		}
+		m.result, m.changes = msg.result, msg.result.Changes
+		m.excluded = make(map[string]bool)
+		m.fileList = listState{}
+		m.screen = fileScreen
+		m.setStatus(fmt.Sprintf("%d files changed", len(m.changes)))
+		return m, nil
+
+	case diffMsg:
+		if !m.finishLoad(msg.id) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.setError(fmt.Sprintf("Failed to diff %s: %v", msg.path, msg.err))
+			return m, nil
+		}
+		m.diffPath, m.diffLines = msg.path, msg.lines
+		m.diffList = listState{}
+		m.screen = diffScreen
+		m.setStatus("")
+		return m, nil
+
+	case exportMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.setError(fmt.Sprintf("Export failed: %v", msg.err))
+			return m, nil
+		}
+		m.exported = append(m.exported, msg.path)
+		m.setStatus(fmt.Sprintf("Archive created: %s", msg.path))
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey applies a key press to the current screen
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	switch key {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "backspace", "left", "h":
+		// The screen a load was started for is being left: drop its result
+		m.abandonLoad()
+		if m.screen > refScreen {
+			m.screen--
+			m.setStatus("")
+		}
+		return m, nil
+	}
+	if m.loading {
+		return m, nil
+	}
+
+	list, n := m.currentList()
+	switch key {
+	case "up", "k":
+		list.move(-1, n, m.rows())
+	case "down", "j":
+		list.move(1, n, m.rows())
+	case "pgup", "ctrl+b":
+		list.move(-m.rows(), n, m.rows())
+	case "pgdown", "ctrl+f", "f":
+		list.move(m.rows(), n, m.rows())
+	case "home", "g":
+		list.move(-n, n, m.rows())
+	case "end", "G":
+		list.move(n, n, m.rows())
+	default:
+		switch m.screen {
+		case refScreen:
+			return m.handleRefKey(key)
+		case commitScreen:
+			return m.handleCommitKey(key)
+		case fileScreen:
+			return m.handleFileKey(key)
+		}
+		return m, nil
+	}
+
+	// Fetch the next page when scrolling reaches the end of the loaded commits
+	if m.screen == commitScreen && !m.commitsDone && m.commitList.pos >= len(m.commits)-1 {
+		return m, m.loadCommits(m.ref, len(m.commits))
+	}
+	return m, nil
+}
+
+func (m *model) handleRefKey(key string) (tea.Model, tea.Cmd) {
+	if (key == "enter" || key == "right" || key == "l") && len(m.refs) > 0 {
+		ref := m.refs[m.refList.pos]
+		if ref == m.ref && len(m.commits) > 0 {
+			m.screen = commitScreen
+			return m, nil
+		}
+		return m, m.loadCommits(ref, 0)
+	}
+	return m, nil
+}
+
+func (m *model) handleCommitKey(key string) (tea.Model, tea.Cmd) {
+	if len(m.commits) == 0 {
+		return m, nil
+	}
+	commit := m.commits[m.commitList.pos]
+	switch key {
+	case "s":
+		m.start = commit.Hash
+		m.setStatus(fmt.Sprintf("Start: %s", commit.ShortHash))
+	case "e":
+		m.end = commit.Hash
+		m.setStatus(fmt.Sprintf("End: %s", commit.ShortHash))
+	case "enter", "right", "l":
+		if m.start == "" || m.end == "" {
+			m.setError("Mark a start commit with s and an end commit with e first")
+			return m, nil
+		}
+		return m, m.loadChanges()
+	}
+	return m, nil
+}
+
+func (m *model) handleFileKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case " ", "space":
+		if len(m.changes) > 0 {
+			path := m.changes[m.fileList.pos].Path
+			m.excluded[path] = !m.excluded[path]
+			m.fileList.move(1, len(m.changes), m.rows())
+		}
+	case "a":
+		// Exclude everything when all files are included, otherwise include all
+		excludeAll := m.includedCount() == len(m.changes)
+		m.excluded = make(map[string]bool)
+		if excludeAll {
+			for _, change := range m.changes {
+				m.excluded[change.Path] = true
+			}
+		}
+	case "enter", "right", "l":
+		if len(m.changes) > 0 {
+			return m, m.loadDiff(m.changes[m.fileList.pos])
+		}
+	case "x":
+		selected := m.selectedChanges()
+		if len(selected) == 0 {
+			m.setError("No files selected for the archive")
+			return m, nil
+		}
+		if m.opts.Export == nil {
+			return m, nil
+		}
+		m.loading = true
+		m.setStatus(fmt.Sprintf("Exporting %d files...", len(selected)))
+		ctx, result, export := m.ctx, m.withChanges(selected), m.opts.Export
+		return m, func() tea.Msg {
+			path, err := export(ctx, result)
+			return exportMsg{path: path, err: err}
+		}
+	}
+	return m, nil
+}
+
+// currentList returns the cursor of the visible list and its length
+func (m *model) currentList() (*listState, int) {
+	switch m.screen {
+	case commitScreen:
+		return &m.commitList, len(m.commits)
+	case fileScreen:
+		return &m.fileList, len(m.changes)
+	case diffScreen:
+		return &m.diffList, len(m.diffLines)
+	}
+	return &m.refList, len(m.refs)
+}
+
+// selectedChanges returns the changes that are not toggled out
+func (m *model) selectedChanges() []git.FileChange {
+	selected := []git.FileChange{}
+	for _, change := range m.changes {
+		if !m.excluded[change.Path] {
+			selected = append(selected, change)
+		}
+	}
+	return selected
+}
+
+// includedCount returns how many changes will be archived
+func (m *model) includedCount() int {
+	return len(m.selectedChanges())
+}
+
+// withChanges returns a copy of the comparison limited to changes
+func (m *model) withChanges(changes []git.FileChange) *git.Result {
+	result := *m.result
+	result.Changes = changes
+	return &result
+}
+
+// startLoad starts a background load for the current screen, returning its
+// context and the id its message must carry
+func (m *model) startLoad(status string) (context.Context, int) {
+	m.abandonLoad()
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.loadID++
+	m.cancelLoad = cancel
+	m.loading = true
+	m.setStatus(status)
+	return ctx, m.loadID
+}
+
+// finishLoad ends the load that sent the message with id and reports whether
+// it is still wanted; results of abandoned loads are dropped
+func (m *model) finishLoad(id int) bool {
+	if m.cancelLoad == nil || id != m.loadID {
+		return false
+	}
+	m.cancelLoad()
+	m.cancelLoad = nil
+	m.loading = false
+	return true
+}
+
+// abandonLoad cancels the running load, if any, so its result is dropped
+func (m *model) abandonLoad() {
+	if m.cancelLoad == nil {
+		return
+	}
+	m.cancelLoad()
+	m.cancelLoad = nil
+	m.loading = false
+}
+
+func (m *model) setStatus(text string) {
+	m.status, m.isError = text, false
+}
+
+func (m *model) setError(text string) {
+	m.status, m.isError = text, true
+}
+
+// loadCommits loads a page of the history of ref
+func (m *model) loadCommits(ref string, skip int) tea.Cmd {
+	ctx, id := m.startLoad(fmt.Sprintf("Loading commits of %s...", ref))
+	repo := m.opts.Repo
+	return func() tea.Msg {
+		commits, err := git.ListCommitsPage(ctx, repo, ref, skip, commitPageSize)
+		return commitsMsg{id: id, ref: ref, commits: commits, err: err}
+	}
+}
+
+// loadChanges compares the marked commits. Unless the comparison is from
+// their merge base, an end commit that does not build on the start commit is
+// refused.
+func (m *model) loadChanges() tea.Cmd {
+	ctx, id := m.startLoad("Comparing changes...")
+	opts, start, end := m.opts, m.start, m.end
+	return func() tea.Msg {
+		if start == end {
+			return changesMsg{id: id, err: fmt.Errorf("start and end are the same commit")}
+		}
+		result, err := opts.Compare(ctx, start, end)
+		if err != nil {
+			return changesMsg{id: id, err: err}
+		}
+		if result.MergeBase == "" {
+			newer, err := git.IsAncestor(opts.Repo, start, end)
+			if err != nil {
+				return changesMsg{id: id, err: err}
+			}
+			if !newer {
+				return changesMsg{id: id, err: fmt.Errorf("end commit %s does not build on start commit %s", end[:7], start[:7])}
+			}
+		}
+		return changesMsg{id: id, result: result}
+	}
+}
+
+// loadDiff renders the unified diff of a single changed file
+func (m *model) loadDiff(change git.FileChange) tea.Cmd {
+	ctx, id := m.startLoad(fmt.Sprintf("Diffing %s...", change.Path))
+	result, writePatch := m.withChanges([]git.FileChange{change}), m.opts.WritePatch
+	return func() tea.Msg {
+		var buf bytes.Buffer
+		if err := writePatch(ctx, &buf, result); err != nil {
+			return diffMsg{id: id, path: change.Path, err: err}
+		}
+		text := strings.ReplaceAll(strings.TrimRight(buf.String(), "\n"), "\t", "    ")
+		return diffMsg{id: id, path: change.Path, lines: strings.Split(text, "\n")}
+	}
+}
@@ -0,0 +1,174 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/githubCompare/internal/git"
+)
+
+func press(m *model, key string) tea.Cmd {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	case " ":
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	_, cmd := m.Update(msg)
+	return cmd
+}
+
+func TestModelNavigation(t *testing.T) {
+	m := newModel(Options{
+		Branches: []git.Branch{{Name: "main"}},
+		Tags:     []git.Tag{{Name: "v1.0.0"}},
+	})
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 10})
+
+	if cmd := press(m, "enter"); cmd == nil || !m.loading {
+		t.Fatalf("enter on a branch should load its commits")
+	}
+	m.Update(commitsMsg{id: m.loadID, ref: "main", commits: []git.Commit{
+		{Hash: "cccccccccc", ShortHash: "ccccccc"},
+		{Hash: "bbbbbbbbbb", ShortHash: "bbbbbbb"},
+		{Hash: "aaaaaaaaaa", ShortHash: "aaaaaaa"},
+	}})
+	if m.screen != commitScreen || !m.commitsDone {
+		t.Fatalf("screen = %v, commitsDone = %v, want the commit screen with all commits loaded", m.screen, m.commitsDone)
+	}
+
+	// Comparing needs both marks
+	if cmd := press(m, "enter"); cmd != nil || !m.isError {
+		t.Errorf("enter without marks should report an error")
+	}
+	press(m, "e")
+	press(m, "down")
+	press(m, "down")
+	press(m, "s")
+	if m.start != "aaaaaaaaaa" || m.end != "cccccccccc" {
+		t.Fatalf("start, end = %s, %s, want aaaaaaaaaa, cccccccccc", m.start, m.end)
+	}
+	if cmd := press(m, "enter"); cmd == nil {
+		t.Fatalf("enter with both marks should compare")
+	}
+
+	m.Update(changesMsg{id: m.loadID, result: &git.Result{Changes: []git.FileChange{
+		{Path: "a.txt", ChangeType: "modified"},
+		{Path: "b.txt", ChangeType: "added"},
+	}}})
+	if m.screen != fileScreen {
+		t.Fatalf("screen = %v, want the file screen", m.screen)
+	}
+
+	press(m, " ")
+	if selected := m.selectedChanges(); len(selected) != 1 || selected[0].Path != "b.txt" {
+		t.Errorf("selectedChanges() after toggling a.txt = %v, want only b.txt", selected)
+	}
+	press(m, "a")
+	if n := m.includedCount(); n != 2 {
+		t.Errorf("includedCount() after toggle all = %d, want 2", n)
+	}
+	press(m, "a")
+	if n := m.includedCount(); n != 0 {
+		t.Errorf("includedCount() after second toggle all = %d, want 0", n)
+	}
+	if cmd := press(m, "x"); cmd != nil || !m.isError {
+		t.Errorf("exporting no files should report an error")
+	}
+
+	press(m, "esc")
+	if m.screen != commitScreen {
+		t.Errorf("esc from the file screen went to %v, want the commit screen", m.screen)
+	}
+}
+
+func TestModelLoadsMoreCommits(t *testing.T) {
+	m := newModel(Options{Branches: []git.Branch{{Name: "main"}}})
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 10})
+
+	page := make([]git.Commit, commitPageSize)
+	for i := range page {
+		page[i] = git.Commit{Hash: fmt.Sprintf("%040d", i)}
+	}
+	m.loadCommits("main", 0)
+	m.Update(commitsMsg{id: m.loadID, ref: "main", commits: page})
+	if m.commitsDone {
+		t.Fatalf("a full page should leave more commits to load")
+	}
+
+	m.commitList.pos = commitPageSize - 2
+	if cmd := press(m, "down"); cmd == nil {
+		t.Errorf("reaching the last loaded commit should load the next page")
+	}
+}
+
+func TestModelDropsAbandonedLoads(t *testing.T) {
+	m := newModel(Options{Branches: []git.Branch{{Name: "main"}}})
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 10})
+	m.loadCommits("main", 0)
+	m.Update(commitsMsg{id: m.loadID, ref: "main", commits: []git.Commit{{Hash: "bbbbbbbbbb"}, {Hash: "aaaaaaaaaa"}}})
+	m.start, m.end = "aaaaaaaaaa", "bbbbbbbbbb"
+
+	// Leaving the commit screen while comparing drops the late result
+	press(m, "enter")
+	id := m.loadID
+	press(m, "esc")
+	if m.loading || m.screen != refScreen {
+		t.Fatalf("after esc: loading = %v, screen = %v, want idle on the ref screen", m.loading, m.screen)
+	}
+	m.Update(changesMsg{id: id, result: &git.Result{Changes: []git.FileChange{{Path: "a.txt"}}}})
+	if m.screen != refScreen || m.changes != nil {
+		t.Errorf("abandoned changesMsg moved to %v with changes %v, want to stay on the ref screen", m.screen, m.changes)
+	}
+
+	// So does a late diff after going back to the file list
+	press(m, "enter")
+	press(m, "enter")
+	m.Update(changesMsg{id: m.loadID, result: &git.Result{Changes: []git.FileChange{{Path: "a.txt"}}}})
+	press(m, "enter")
+	id = m.loadID
+	press(m, "esc")
+	m.Update(diffMsg{id: id, path: "a.txt", lines: []string{"diff"}})
+	if m.screen != commitScreen || m.diffLines != nil {
+		t.Errorf("abandoned diffMsg moved to %v with lines %v, want to stay on the commit screen", m.screen, m.diffLines)
+	}
+}
+
+func TestLoadChangesMergeBase(t *testing.T) {
+	result := &git.Result{MergeBase: "0000000000", Changes: []git.FileChange{{Path: "a.txt"}}}
+	m := newModel(Options{
+		Compare: func(ctx context.Context, start, end string) (*git.Result, error) {
+			return result, nil
+		},
+	})
+	m.start, m.end = "aaaaaaaaaa", "bbbbbbbbbb"
+
+	// Diverged commits compare from their merge base without an ancestry check
+	msg, ok := m.loadChanges()().(changesMsg)
+	if !ok || msg.err != nil || msg.result != result {
+		t.Errorf("loadChanges() = %+v, want the merge-base comparison", msg)
+	}
+}
+
+func TestListStateMove(t *testing.T) {
+	l := listState{}
+	l.move(7, 10, 5)
+	if l.pos != 7 || l.offset != 3 {
+		t.Errorf("after moving down: pos, offset = %d, %d, want 7, 3", l.pos, l.offset)
+	}
+	l.move(20, 10, 5)
+	if l.pos != 9 || l.offset != 5 {
+		t.Errorf("after moving past the end: pos, offset = %d, %d, want 9, 5", l.pos, l.offset)
+	}
+	l.move(-8, 10, 5)
+	if l.pos != 1 || l.offset != 1 {
+		t.Errorf("after moving up: pos, offset = %d, %d, want 1, 1", l.pos, l.offset)
+	}
+}
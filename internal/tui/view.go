@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/githubCompare/internal/display"
+	"github.com/githubCompare/internal/git"
+)
+
+// Styles, matching the colors of the line-oriented output in internal/display
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	subtleStyle   = lipgloss.NewStyle().Faint(true)
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	branchStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	commitStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	deletedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	hunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	errorStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1"))
+	markStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2"))
+	excludedStyle = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+)
+
+// help lists the keys of each screen
+var help = map[screen]string{
+	refScreen:    "↑/↓ move • enter open • q quit",
+	commitScreen: "↑/↓ move • s mark start • e mark end • enter compare • esc back • q quit",
+	fileScreen:   "↑/↓ move • space toggle • a toggle all • enter diff • x export • esc back • q quit",
+	diffScreen:   "↑/↓ scroll • pgup/pgdn page • esc back • q quit",
+}
+
+func (m *model) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var b strings.Builder
+	b.WriteString(m.fit(headerStyle, "githubCompare  "+m.opts.Title) + "\n")
+	b.WriteString(m.fit(subtleStyle, m.breadcrumb()) + "\n")
+
+	lines := m.listLines()
+	for i := 0; i < m.rows(); i++ {
+		if i < len(lines) {
+			b.WriteString(lines[i])
+		}
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.isError:
+		b.WriteString(m.fit(errorStyle, m.status))
+	default:
+		b.WriteString(m.fit(lipgloss.NewStyle(), m.status))
+	}
+	b.WriteString("\n" + m.fit(subtleStyle, help[m.screen]))
+	return b.String()
+}
+
+// breadcrumb describes where in the browser the user is
+func (m *model) breadcrumb() string {
+	switch m.screen {
+	case commitScreen:
+		return fmt.Sprintf("%s  start %s  end %s", m.ref, shortHash(m.start), shortHash(m.end))
+	case fileScreen:
+		return fmt.Sprintf("%s..%s  %d of %d files selected", shortHash(m.start), shortHash(m.end), m.includedCount(), len(m.changes))
+	case diffScreen:
+		return fmt.Sprintf("%s..%s  %s", shortHash(m.start), shortHash(m.end), m.diffPath)
+	}
+	return "Select a branch or tag"
+}
+
+// listLines renders the visible rows of the current screen
+func (m *model) listLines() []string {
+	list, n := m.currentList()
+	lines := []string{}
+	for i := list.offset; i < n && i < list.offset+m.rows(); i++ {
+		selected := i == list.pos && m.screen != diffScreen
+		lines = append(lines, m.renderRow(i, selected))
+	}
+	if m.screen == commitScreen && !m.commitsDone && len(lines) < m.rows() {
+		lines = append(lines, subtleStyle.Render("  … scroll down to load more"))
+	}
+	return lines
+}
+
+// renderRow renders row i of the current screen
+func (m *model) renderRow(i int, selected bool) string {
+	style := lipgloss.NewStyle()
+	var text string
+	switch m.screen {
+	case refScreen:
+		text = "  " + m.refs[i]
+		if i >= len(m.opts.Branches) {
+			text += "  (tag)"
+		}
+		style = branchStyle
+	case commitScreen:
+		commit := m.commits[i]
+		text = commitMark(commit.Hash, m.start, m.end) + display.FormatCommitOption(commit, i)
+		if commit.Hash == m.start || commit.Hash == m.end {
+			style = markStyle
+		} else {
+			style = commitStyle
+		}
+	case fileScreen:
+		change := m.changes[i]
+		check := "[x] "
+		if m.excluded[change.Path] {
+			check = "[ ] "
+			style = excludedStyle
+		} else {
+			style = changeStyle(change)
+		}
+		text = check + display.FormatChangeOption(change)
+	case diffScreen:
+		text = m.diffLines[i]
+		style = diffLineStyle(text)
+	}
+
+	if selected {
+		style = style.Inherit(cursorStyle)
+	}
+	return m.fit(style, text)
+}
+
+// fit renders text with style, truncated to the terminal width
+func (m *model) fit(style lipgloss.Style, text string) string {
+	return style.MaxWidth(m.width).Render(text)
+}
+
+// commitMark labels the marked start and end commits
+func commitMark(hash, start, end string) string {
+	switch hash {
+	case start:
+		return "S "
+	case end:
+		return "E "
+	}
+	return "  "
+}
+
+// changeStyle colors a changed file by its change type
+func changeStyle(change git.FileChange) lipgloss.Style {
+	switch change.ChangeType {
+	case "added":
+		return addedStyle
+	case "deleted":
+		return deletedStyle
+	case "renamed", "copied":
+		return branchStyle
+	}
+	return commitStyle
+}
+
+// diffLineStyle colors a line of a unified diff
+func diffLineStyle(line string) lipgloss.Style {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff "):
+		return lipgloss.NewStyle().Bold(true)
+	case strings.HasPrefix(line, "@@"):
+		return hunkStyle
+	case strings.HasPrefix(line, "+"):
+		return addedStyle
+	case strings.HasPrefix(line, "-"):
+		return deletedStyle
+	}
+	return lipgloss.NewStyle()
+}
+
+// shortHash abbreviates a commit hash, showing "-" when none is set
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -28,3 +28,14 @@ func EnsureDir(path string) error {
 func GetTempBase() string {
 	return filepath.Join(os.TempDir(), "githubCompare")
 }
+
+// GetCacheBase returns the directory holding cached repository mirrors.
+// It lives in the user cache directory so mirrors survive reboots, falling
+// back to the temp base when no user cache directory is available.
+func GetCacheBase() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(GetTempBase(), "mirrors")
+	}
+	return filepath.Join(cacheDir, "githubCompare", "mirrors")
+}
@@ -2,8 +2,9 @@ package utils
 
 // RepoInfo contains parsed repository information
 type RepoInfo struct {
-	URL      string
-	Name     string
-	Owner    string
-	Protocol string // "https" or "ssh"
+	URL      string `json:"url" yaml:"url"`
+	Name     string `json:"name" yaml:"name"`
+	Owner    string `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Host     string `json:"host,omitempty" yaml:"host,omitempty"`
+	Protocol string `json:"protocol" yaml:"protocol"` // "https", "ssh" or "file"
 }
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -29,6 +32,7 @@ func ParseRepoURL(url string) (*RepoInfo, error) {
 		re := regexp.MustCompile(`https?://(?:[^@]+@)?([^/]+)/([^/]+)/([^/]+)`)
 		matches := re.FindStringSubmatch(url)
 		if len(matches) == 4 {
+			info.Host = matches[1]
 			info.Owner = matches[2]
 			info.Name = matches[3]
 			return info, nil
@@ -40,6 +44,7 @@ func ParseRepoURL(url string) (*RepoInfo, error) {
 		re := regexp.MustCompile(`(?:ssh://)?(?:git@)?([^:]+):([^/]+)/([^/]+)`)
 		matches := re.FindStringSubmatch(url)
 		if len(matches) == 4 {
+			info.Host = matches[1]
 			info.Owner = matches[2]
 			info.Name = matches[3]
 			return info, nil
@@ -49,6 +54,90 @@ func ParseRepoURL(url string) (*RepoInfo, error) {
 	return nil, fmt.Errorf("unable to parse repository URL: %s", url)
 }
 
+// NormalizeRepoURL reduces a repository URL to a canonical host/path form so
+// that HTTPS and SSH spellings of the same repository compare equal, e.g.
+// "git@GitHub.com:owner/repo.git" becomes "github.com/owner/repo"
+func NormalizeRepoURL(url string) string {
+	url = strings.TrimSpace(url)
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+
+	// Strip the scheme; scp-like SSH URLs use host:path instead
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		url = url[idx+3:]
+	} else if idx := strings.Index(url, ":"); idx >= 0 {
+		url = url[:idx] + "/" + url[idx+1:]
+	}
+
+	// Strip credentials or the ssh user
+	if idx := strings.Index(url, "@"); idx >= 0 && idx < strings.Index(url+"/", "/") {
+		url = url[idx+1:]
+	}
+
+	// Hosts are case-insensitive, paths may not be
+	host, path, _ := strings.Cut(url, "/")
+	return strings.ToLower(host) + "/" + path
+}
+
+// CacheKey returns a filesystem-safe directory name for caching the
+// repository at url. Equivalent URLs map to the same key.
+func CacheKey(url string) string {
+	normalized := NormalizeRepoURL(url)
+	sum := sha256.Sum256([]byte(normalized))
+
+	readable := regexp.MustCompile(`[^A-Za-z0-9._-]+`).ReplaceAllString(normalized, "_")
+	if len(readable) > 64 {
+		readable = readable[len(readable)-64:]
+	}
+
+	return readable + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// StripCredentials removes a user and password from an HTTP(S) URL. SSH
+// users are kept, as they name the account rather than a secret.
+func StripCredentials(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.User == nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+// IsLocalPath reports whether source refers to a directory on disk rather than
+// a remote URL. A file:// prefix is accepted and stripped.
+func IsLocalPath(source string) bool {
+	path := strings.TrimPrefix(source, "file://")
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ParseLocalPath builds repository information for a repository on disk
+func ParseLocalPath(path string) (*RepoInfo, error) {
+	absPath, err := filepath.Abs(strings.TrimPrefix(path, "file://"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve local path %s: %w", path, err)
+	}
+
+	return &RepoInfo{
+		URL:      absPath,
+		Name:     strings.TrimSuffix(filepath.Base(absPath), ".git"),
+		Protocol: "file",
+	}, nil
+}
+
+// WebURL returns the browsable HTTPS address of a hosted repository, e.g.
+// "https://github.com/owner/repo", or "" for repositories without a host
+func (r *RepoInfo) WebURL() string {
+	if r.Host == "" || r.Owner == "" || r.Name == "" {
+		return ""
+	}
+	return "https://" + strings.ToLower(r.Host) + "/" + r.Owner + "/" + r.Name
+}
+
 // ValidateAuth tests if authentication is needed and available
 func ValidateAuth(url string) bool {
 	// For SSH, check if SSH key exists
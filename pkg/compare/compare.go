@@ -0,0 +1,325 @@
+// Package compare finds the files changed between two references of a Git
+// repository and exports them as a ZIP archive. It is the library behind the
+// githubCompare command-line tool and never prints or exits.
+//
+//	c, err := compare.New(compare.Options{
+//		URL:         "https://github.com/owner/repo",
+//		Start:       "v1.0.0",
+//		End:         "main",
+//		Diff:        compare.DefaultDiffOptions,
+//		ArchivePath: "changes.zip",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	result, err := c.Run(ctx)
+package compare
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/githubCompare/internal/filter"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/handle"
+	"github.com/githubCompare/internal/utils"
+)
+
+// Options configures a Comparer
+type Options struct {
+	// Source: exactly one of URL and Path
+	URL       string    // Remote repository, HTTPS or SSH
+	Path      string    // Repository on disk, used in place
+	AuthToken string    // Token for private HTTPS repositories
+	CacheDir  string    // Keep remote repositories as bare mirrors here; empty clones into a temporary directory
+	KeepClone bool      // Leave the temporary clone on disk on Close
+	InMemory  bool      // Clone URL into memory instead; CacheDir and KeepClone must be unset
+	Progress  io.Writer // Receives clone and fetch progress; nil to discard
+
+	// References, used by Run
+	Start     string
+	End       string
+	MergeBase bool // Compare End against the merge base of Start and End
+
+	// Change detection and path filters
+	Diff       DiffOptions
+	Include    []string // Glob or pathspec patterns; empty includes everything
+	Exclude    []string
+	IgnoreFile string // File with one exclude pattern per line
+
+	// Output sinks, used by Run, WriteArchive and WriteArchiveTo
+	ArchivePath   string    // ZIP archive to create; empty to only compare
+	ArchiveWriter io.Writer // Stream the ZIP archive here instead of to ArchivePath
+	PatchPath     string    // Also write a git apply-compatible unified diff here
+	EmbedPatch    bool      // Embed the unified diff in the archive
+	Changelog     bool      // Embed a log of the range's commits in the archive
+	NoManifest    bool      // Leave out the change manifest and apply scripts
+}
+
+// OptionError reports an invalid Options field
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Option, e.Err)
+}
+
+func (e *OptionError) Unwrap() error { return e.Err }
+
+// Comparer compares references of one repository. Open it explicitly or let
+// Compare and Run do so, and Close it when done.
+type Comparer struct {
+	opts    Options
+	info    *RepoInfo
+	filter  *filter.Filter
+	repo    *git.Repository // Once opened
+	tempDir string          // Temporary clone to remove on Close
+}
+
+// New validates opts and returns a Comparer for the repository they name.
+// Nothing is cloned or fetched until the repository is opened.
+func New(opts Options) (*Comparer, error) {
+	var info *RepoInfo
+	var err error
+	switch {
+	case opts.URL != "" && opts.Path != "":
+		return nil, &OptionError{Option: "source", Err: fmt.Errorf("URL and Path cannot be used together")}
+	case opts.Path != "":
+		info, err = utils.ParseLocalPath(opts.Path)
+	case opts.URL != "":
+		info, err = utils.ParseRepoURL(opts.URL)
+	default:
+		return nil, &OptionError{Option: "source", Err: fmt.Errorf("either URL or Path is required")}
+	}
+	if err != nil {
+		return nil, &OptionError{Option: "repository URL", Err: err}
+	}
+	// Results are printed and shared, only Open needs the credentials
+	info.URL = utils.StripCredentials(info.URL)
+	if opts.InMemory && (opts.URL == "" || opts.CacheDir != "" || opts.KeepClone) {
+		return nil, &OptionError{Option: "source", Err: fmt.Errorf("InMemory needs a URL and no CacheDir or KeepClone")}
+	}
+
+	if opts.ArchivePath != "" && opts.ArchiveWriter != nil {
+		return nil, &OptionError{Option: "archive", Err: fmt.Errorf("ArchivePath and ArchiveWriter cannot be used together")}
+	}
+
+	if opts.Diff.RenameThreshold < 0 || opts.Diff.RenameThreshold > 100 {
+		return nil, &OptionError{Option: "rename threshold", Err: fmt.Errorf("%d is not between 0 and 100", opts.Diff.RenameThreshold)}
+	}
+
+	pathFilter, err := filter.New(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, &OptionError{Option: "path filter", Err: err}
+	}
+	if opts.IgnoreFile != "" {
+		if err := pathFilter.AddIgnoreFile(opts.IgnoreFile); err != nil {
+			return nil, &OptionError{Option: "ignore file", Err: err}
+		}
+	}
+
+	return &Comparer{opts: opts, info: info, filter: pathFilter}, nil
+}
+
+// Repository returns the parsed repository URL or path
+func (c *Comparer) Repository() *RepoInfo {
+	return c.info
+}
+
+// Path returns the directory of the repository on disk, or "" before it is
+// opened or for an in-memory clone
+func (c *Comparer) Path() string {
+	if c.repo == nil {
+		return ""
+	}
+	return c.repo.Path()
+}
+
+func init() {
+	handle.Repo = func(c any) *git.Repository { return c.(*Comparer).repo }
+}
+
+// Open makes the repository available: a local repository is used in place,
+// a remote one is synced into its cached mirror or cloned into a temporary
+// directory or, with Options.InMemory, into memory. When Options.Start and
+// Options.End are both set, a new mirror or the temporary or in-memory clone
+// fetches only the refs they name, as deep as the range needs. Opening again
+// is a no-op. Cancelling ctx aborts the clone or fetch.
+func (c *Comparer) Open(ctx context.Context) error {
+	_, err := c.open(ctx)
+	return err
+}
+
+// open is Open returning the repository
+func (c *Comparer) open(ctx context.Context) (*git.Repository, error) {
+	if c.repo != nil {
+		return c.repo, nil
+	}
+
+	var path string
+	switch {
+	case c.opts.Path != "":
+		root, err := git.OpenLocalRepository(c.opts.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open repository: %w", err)
+		}
+		path = root
+		// Report the worktree root rather than the directory given
+		if c.info, err = utils.ParseLocalPath(root); err != nil {
+			return nil, err
+		}
+	case c.opts.InMemory:
+		repo, err := git.CloneMemory(ctx, git.CloneOptions{
+			URL:       c.opts.URL,
+			AuthToken: c.opts.AuthToken,
+			Progress:  c.opts.Progress,
+			Start:     c.opts.Start,
+			End:       c.opts.End,
+			MergeBase: c.opts.MergeBase,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.repo = repo
+		return c.repo, nil
+	case c.opts.CacheDir != "":
+		// Reuse the cached bare mirror, fetching only what changed
+		mirror, err := git.SyncMirror(ctx, git.MirrorOptions{
+			URL:       c.opts.URL,
+			AuthToken: c.opts.AuthToken,
+			Path:      filepath.Join(c.opts.CacheDir, utils.CacheKey(c.opts.URL)),
+			Progress:  c.opts.Progress,
+			Start:     c.opts.Start,
+			End:       c.opts.End,
+			MergeBase: c.opts.MergeBase,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update mirror: %w", err)
+		}
+		path = mirror
+	default:
+		tempDir, err := utils.CreateTempDir("githubCompare-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		clone, err := git.CloneRepository(ctx, git.CloneOptions{
+			URL:       c.opts.URL,
+			AuthToken: c.opts.AuthToken,
+			TempDir:   tempDir,
+			Progress:  c.opts.Progress,
+			Start:     c.opts.Start,
+			End:       c.opts.End,
+			MergeBase: c.opts.MergeBase,
+		})
+		if err != nil {
+			utils.CleanupTemp(tempDir)
+			return nil, err
+		}
+		path, c.tempDir = clone, tempDir
+	}
+
+	repo, err := git.OpenRepository(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	c.repo = repo
+	return c.repo, nil
+}
+
+// Close removes the temporary clone, unless Options.KeepClone is set, and
+// releases the repository, freeing an in-memory clone
+func (c *Comparer) Close() error {
+	c.repo = nil
+	if c.tempDir == "" || c.opts.KeepClone {
+		return nil
+	}
+	if err := utils.CleanupTemp(c.tempDir); err != nil {
+		return fmt.Errorf("failed to cleanup temp directory: %w", err)
+	}
+	c.tempDir = ""
+	return nil
+}
+
+// Compare opens the repository if needed and returns the commits between
+// start and end and the files they changed, narrowed by the path filters
+func (c *Comparer) Compare(ctx context.Context, start, end string) (*Result, error) {
+	repo, err := c.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := git.ValidateRefs(repo, start, end); err != nil {
+		return nil, fmt.Errorf("reference validation failed: %w", err)
+	}
+
+	result := &Result{
+		Repository: c.info,
+		Start:      ResolvedRef{Ref: start},
+		End:        ResolvedRef{Ref: end},
+	}
+	if result.Start.Hash, err = git.GetCommitHash(repo, start); err != nil {
+		return nil, fmt.Errorf("failed to resolve start commit: %w", err)
+	}
+	if result.End.Hash, err = git.GetCommitHash(repo, end); err != nil {
+		return nil, fmt.Errorf("failed to resolve end commit: %w", err)
+	}
+
+	// Compare from the common ancestor so changes on the start side since the
+	// branch point don't show up as reverted
+	if c.opts.MergeBase {
+		if result.MergeBase, err = git.MergeBase(repo, start, end); err != nil {
+			return nil, fmt.Errorf("failed to find merge base: %w", err)
+		}
+	}
+
+	if result.Commits, err = git.ListCommitRange(ctx, repo, result.Base(), end); err != nil {
+		return nil, fmt.Errorf("failed to list commits in range: %w", err)
+	}
+
+	changes, err := git.GetChangedFiles(ctx, repo, result.Base(), end, c.opts.Diff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare changes: %w", err)
+	}
+	result.TotalChanges = len(changes)
+	result.Changes = c.Filter(changes)
+
+	return result, nil
+}
+
+// Filter returns the changes matched by the path filters, keeping renames and
+// copies whose old or new path matches
+func (c *Comparer) Filter(changes []FileChange) []FileChange {
+	if c.filter.IsEmpty() {
+		return changes
+	}
+	return c.filter.Apply(changes)
+}
+
+// Run compares Options.Start and Options.End and, when Options.ArchivePath or
+// Options.ArchiveWriter is set, writes the archive. An empty range returns the result along with an
+// *EmptyRangeError and creates no archive.
+func (c *Comparer) Run(ctx context.Context) (*Result, error) {
+	result, err := c.Compare(ctx, c.opts.Start, c.opts.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Changes) == 0 {
+		return result, &EmptyRangeError{Start: result.Base(), End: result.End.Ref}
+	}
+	switch {
+	case c.opts.ArchiveWriter != nil:
+		if err := c.WriteArchiveTo(ctx, c.opts.ArchiveWriter, result); err != nil {
+			return result, err
+		}
+	case c.opts.ArchivePath != "":
+		if err := c.WriteArchive(ctx, result, c.opts.ArchivePath); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/githubCompare/internal/archive"
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/utils"
+)
+
+// WriteArchive writes the archive of result to a file at outputPath, see
+// WriteArchiveTo, and records the file's absolute path in result.Archive.
+// Files written before a failure or cancellation of ctx are removed.
+func (c *Comparer) WriteArchive(ctx context.Context, result *Result, outputPath string) (err error) {
+	// Ensure output directory exists
+	if err := archive.EnsureOutputDir(outputPath); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create ZIP file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close ZIP file: %w", closeErr)
+		}
+		if err != nil {
+			os.Remove(outputPath)
+		}
+	}()
+
+	if err := c.WriteArchiveTo(ctx, file, result); err != nil {
+		return err
+	}
+
+	if result.Archive, err = filepath.Abs(outputPath); err != nil {
+		return fmt.Errorf("failed to resolve archive path: %w", err)
+	}
+	return nil
+}
+
+// WriteArchiveTo streams a ZIP archive of the files in result.Changes, as of
+// result.End, to w, along with the patch, changelog and manifest selected by
+// the options. The changelog lists result.Commits, or the commits of the
+// range when it is nil. A patch file written before a failure or cancellation
+// of ctx is removed; what already reached w is not.
+func (c *Comparer) WriteArchiveTo(ctx context.Context, w io.Writer, result *Result) (err error) {
+	repo, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Convert git.FileChange to archive.FileChange
+	archiveChanges := make([]archive.FileChange, len(result.Changes))
+	for i, fc := range result.Changes {
+		archiveChanges[i] = archive.FileChange{
+			Path:       fc.Path,
+			ChangeType: fc.ChangeType,
+			OldPath:    fc.OldPath,
+			Similarity: fc.Similarity,
+			Additions:  fc.Additions,
+			Deletions:  fc.Deletions,
+			Binary:     fc.Binary,
+		}
+	}
+
+	// Generate the unified diff once for both the standalone file and the archive
+	var patch []byte
+	if c.opts.PatchPath != "" || c.opts.EmbedPatch {
+		var buf bytes.Buffer
+		if err := c.WritePatch(ctx, &buf, result); err != nil {
+			return err
+		}
+		patch = buf.Bytes()
+
+		if c.opts.PatchPath != "" {
+			if err := archive.EnsureOutputDir(c.opts.PatchPath); err != nil {
+				return fmt.Errorf("failed to create patch directory: %w", err)
+			}
+			if err := os.WriteFile(c.opts.PatchPath, patch, 0644); err != nil {
+				os.Remove(c.opts.PatchPath)
+				return fmt.Errorf("failed to write patch: %w", err)
+			}
+			defer func() {
+				if err != nil {
+					os.Remove(c.opts.PatchPath)
+				}
+			}()
+		}
+	}
+
+	// The archive is meant to be shared, so it never names a token
+	repoURL := utils.StripCredentials(result.Repository.URL)
+	zipOpts := archive.ZipOptions{}
+	if c.opts.EmbedPatch {
+		zipOpts.Patch = patch
+	}
+	if c.opts.Changelog {
+		commits := result.Commits
+		if commits == nil {
+			if commits, err = git.ListCommitRange(ctx, repo, result.Base(), result.End.Ref); err != nil {
+				return fmt.Errorf("failed to list commits in range: %w", err)
+			}
+		}
+		archiveCommits := make([]archive.Commit, len(commits))
+		for i, commit := range commits {
+			archiveCommits[i] = archive.Commit{
+				ShortHash: commit.ShortHash,
+				Message:   commit.Message,
+				Author:    commit.Author,
+				Date:      commit.Date,
+			}
+		}
+		zipOpts.Changelog = archive.Changelog(repoURL, result.Base(), result.End.Ref, archiveCommits)
+	}
+	if !c.opts.NoManifest {
+		zipOpts.Manifest = archive.NewManifest(repoURL, result.Base(), result.End.Ref, archiveChanges)
+	}
+
+	if err := archive.WriteZip(ctx, w, repo, result.End.Ref, archiveChanges, zipOpts); err != nil {
+		return fmt.Errorf("failed to create ZIP archive: %w", err)
+	}
+	return nil
+}
+
+// WritePatch writes a git apply-compatible unified diff of the files in
+// result.Changes to w
+func (c *Comparer) WritePatch(ctx context.Context, w io.Writer, result *Result) error {
+	repo, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	if err := git.WritePatch(ctx, w, repo, result.Base(), result.End.Ref, c.opts.Diff, changedPaths(result.Changes)); err != nil {
+		return fmt.Errorf("failed to generate patch: %w", err)
+	}
+	return nil
+}
+
+// changedPaths returns a path filter that matches the old and new paths of
+// changes
+func changedPaths(changes []FileChange) func(path string) bool {
+	paths := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		paths[change.Path] = true
+		if change.OldPath != "" {
+			paths[change.OldPath] = true
+		}
+	}
+	return func(path string) bool {
+		return paths[path]
+	}
+}
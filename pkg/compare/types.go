@@ -0,0 +1,29 @@
+package compare
+
+import (
+	"github.com/githubCompare/internal/git"
+	"github.com/githubCompare/internal/utils"
+)
+
+// Types shared with the command-line tool
+type (
+	RepoInfo    = utils.RepoInfo
+	Commit      = git.Commit
+	FileChange  = git.FileChange
+	DiffOptions = git.DiffOptions
+	ResolvedRef = git.ResolvedRef
+	Result      = git.Result
+)
+
+// Errors returned by Comparer, to be matched with errors.As
+type (
+	AuthError         = git.AuthError
+	NetworkError      = git.NetworkError
+	NotFoundError     = git.NotFoundError
+	AmbiguousRefError = git.AmbiguousRefError
+	EmptyRangeError   = git.EmptyRangeError
+)
+
+// DefaultDiffOptions detects renames and counts changed lines, like the
+// command-line tool does by default
+var DefaultDiffOptions = git.DefaultDiffOptions